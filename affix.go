@@ -0,0 +1,98 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"strconv"
+	"unicode"
+)
+
+// AffixStripper is a VariantGenerator that will remove digits,
+// years and symbols from the start and end of a password,
+// so "Summer2024!" will also be looked up as "summer" and
+// "monkey123" as "monkey".
+//
+// Note that the core word must be in the database for it to be found.
+// The DefaultSanitizer rejects passwords shorter than 8 characters, so
+// dictionaries should be imported with a sanitizer that allows
+// shorter passwords, for instance:
+//
+//	opts := password.DefaultSanitizerOptions()
+//	opts.MinRunes = 4
+//	password.ImportWithOptions(in, db, password.ImportOptions{Sanitizer: password.NewSanitizer(opts)})
+type AffixStripper struct {
+	// MinCore is the minimum number of characters that must remain
+	// after stripping. If 0, 4 is used.
+	MinCore int
+}
+
+// Variants returns s with affixes removed.
+func (a AffixStripper) Variants(s string) []string {
+	minCore := a.MinCore
+	if minCore <= 0 {
+		minCore = 4
+	}
+	r := []rune(s)
+
+	// Trailing symbols, then trailing digits.
+	end := len(r)
+	for end > 0 && isAffixSymbol(r[end-1]) {
+		end--
+	}
+	symEnd := end
+	for end > 0 && unicode.IsDigit(r[end-1]) {
+		end--
+	}
+	digEnd := end
+	for end > 0 && isAffix(r[end-1]) {
+		end--
+	}
+
+	// Leading digits and symbols.
+	start := 0
+	for start < len(r) && isAffix(r[start]) {
+		start++
+	}
+
+	var res []string
+	seen := map[string]struct{}{s: {}}
+	addCore := func(from, to int) {
+		if to-from < minCore {
+			return
+		}
+		v := string(r[from:to])
+		if _, ok := seen[v]; ok {
+			return
+		}
+		seen[v] = struct{}{}
+		res = append(res, v)
+	}
+	addCore(0, symEnd)
+	if symEnd-digEnd > 4 && isYear(r[symEnd-4:symEnd]) {
+		// Only the year, for instance "agent0071999".
+		addCore(0, symEnd-4)
+	}
+	addCore(0, digEnd)
+	addCore(0, end)
+	if start < end {
+		addCore(start, len(r))
+		addCore(start, end)
+	}
+	return res
+}
+
+// isAffix returns whether r is a digit or a symbol.
+func isAffix(r rune) bool {
+	return unicode.IsDigit(r) || isAffixSymbol(r)
+}
+
+// isAffixSymbol returns whether r is neither a letter nor a digit.
+func isAffixSymbol(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// isYear returns whether r is a year between 1900 and 2099.
+func isYear(r []rune) bool {
+	y, err := strconv.Atoi(string(r))
+	return err == nil && y >= 1900 && y < 2100
+}
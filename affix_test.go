@@ -0,0 +1,69 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/klauspost/password/drivers/testdb"
+)
+
+func TestAffixStripper(t *testing.T) {
+	tests := map[string][]string{
+		"summer2024!":    {"summer2024", "summer"},
+		"monkey123":      {"monkey"},
+		"agent0071999":   {"agent007", "agent"},
+		"2024summer":     {"summer"},
+		"!!dragon99!!":   {"!!dragon99", "!!dragon", "dragon99!!", "dragon"},
+		"password":       nil,
+		"abc123":         nil,
+		"correct horse1": {"correct horse"},
+	}
+	for in, want := range tests {
+		got := AffixStripper{}.Variants(in)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: expected %q, got %q", in, want, got)
+		}
+	}
+	if got := (AffixStripper{MinCore: 3}).Variants("abc123"); !reflect.DeepEqual(got, []string{"abc"}) {
+		t.Errorf("expected [abc], got %q", got)
+	}
+}
+
+func TestCheckWithAffixes(t *testing.T) {
+	db := testdb.NewMemDB()
+	for _, s := range []string{"summer", "password", "monkey"} {
+		err := db.Add(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := map[string]string{
+		"Summer2024!":   "summer",
+		"monkey12345":   "monkey",
+		"P@ssw0rd1!":    "password",
+		"1999Password":  "password",
+		"P@ssw0rd2024!": "password",
+	}
+	// Stripped words are expanded first, whatever the order of the generators.
+	for _, variants := range [][]VariantGenerator{{Leet{}, AffixStripper{}}, {AffixStripper{}, Leet{}}} {
+		opts := CheckOptions{Variants: variants}
+		for pw, variant := range tests {
+			err := CheckWithOptions(pw, db, opts)
+			var ce *CheckError
+			if !errors.As(err, &ce) || !errors.Is(err, ErrPasswordInDB) {
+				t.Errorf("%q: expected ErrPasswordInDB, got %v", pw, err)
+				continue
+			}
+			if ce.Variant != variant {
+				t.Errorf("%q: expected variant %q, got %q", pw, variant, ce.Variant)
+			}
+		}
+	}
+	opts := CheckOptions{Variants: []VariantGenerator{Leet{}, AffixStripper{}}}
+	if err := CheckWithOptions("summertime2024", db, opts); err != nil {
+		t.Fatal("unexpected error", err)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import "context"
+
+// AllowlistDB is a DB that reports passwords in the Allow DB as
+// not present, even if they are in the Base DB.
+// This can be used to exempt specific entries of a dictionary,
+// without having to remove them from it.
+//
+// The Allow DB can be populated using Import, with the same
+// sanitizer and options as the Base DB, or be an AllowSet.
+type AllowlistDB struct {
+	Base  DB
+	Allow DB
+}
+
+// NewAllowlistDB returns a DB that reports passwords in allow
+// as not present in base.
+func NewAllowlistDB(base, allow DB) *AllowlistDB {
+	return &AllowlistDB{Base: base, Allow: allow}
+}
+
+// Has satisfies the DB interface.
+func (a *AllowlistDB) Has(s string) (bool, error) {
+	return a.HasContext(context.Background(), s)
+}
+
+// HasContext satisfies the DBContext interface.
+// The allowlist is checked first, and the base is only
+// checked if the password isn't allowlisted.
+func (a *AllowlistDB) HasContext(ctx context.Context, s string) (bool, error) {
+	allowed, err := has(ctx, a.Allow, s)
+	if err != nil || allowed {
+		return false, err
+	}
+	return has(ctx, a.Base, s)
+}
+
+// HasMultiple satisfies the BulkDB interface.
+func (a *AllowlistDB) HasMultiple(s []string) ([]bool, error) {
+	return a.HasMultipleContext(context.Background(), s)
+}
+
+// HasMultipleContext satisfies the BulkDBContext interface.
+// Only passwords that aren't allowlisted are looked up in the base.
+func (a *AllowlistDB) HasMultipleContext(ctx context.Context, s []string) ([]bool, error) {
+	allowed, err := hasMultiple(ctx, a.Allow, s)
+	if err != nil {
+		return nil, err
+	}
+	var lookup []string
+	var index []int
+	for i, v := range s {
+		if !allowed[i] {
+			lookup = append(lookup, v)
+			index = append(index, i)
+		}
+	}
+	res := make([]bool, len(s))
+	if len(lookup) == 0 {
+		return res, nil
+	}
+	found, err := hasMultiple(ctx, a.Base, lookup)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range index {
+		res[i] = found[j]
+	}
+	return res, nil
+}
+
+// AllowSet is an in-memory set of passwords, that can be used
+// as the Allow DB of an AllowlistDB.
+// It can be populated using Import or NewAllowSet.
+// It is not safe to add passwords while it is being used.
+type AllowSet map[string]struct{}
+
+// NewAllowSet returns a set with the supplied passwords.
+// The passwords are sanitized and lowercased the same way as by Import.
+// Passwords that do not pass the sanitizer are skipped,
+// since they cannot be in a database either.
+// If nil is passed as Sanitizer, DefaultSanitizer will be used.
+func NewAllowSet(san Sanitizer, passwords ...string) AllowSet {
+	return NewAllowSetWithOptions(ImportOptions{Sanitizer: san}, passwords...)
+}
+
+// NewAllowSetWithOptions returns a set with the supplied passwords,
+// like NewAllowSet, but the passwords are sanitized and folded the same
+// way as by ImportWithOptions with opts.
+// Only the Sanitizer, Confusables and Folding options are used.
+func NewAllowSetWithOptions(opts ImportOptions, passwords ...string) AllowSet {
+	san := opts.Sanitizer
+	if san == nil {
+		san = DefaultSanitizer
+	}
+	a := make(AllowSet, len(passwords))
+	for _, p := range passwords {
+		v, err := san.Sanitize(p)
+		if err != nil {
+			continue
+		}
+		a[dbKey(v, opts.Folding, opts.Confusables)] = struct{}{}
+	}
+	return a
+}
+
+// Add satisfies the DbWriter interface.
+func (a AllowSet) Add(s string) error {
+	a[s] = struct{}{}
+	return nil
+}
+
+// Has satisfies the DB interface.
+func (a AllowSet) Has(s string) (bool, error) {
+	_, ok := a[s]
+	return ok, nil
+}
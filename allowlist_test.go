@@ -0,0 +1,52 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/klauspost/password/drivers/testdb"
+)
+
+func TestAllowlistDB(t *testing.T) {
+	base := testdb.NewMemDBBulk()
+	in := sliceTokenizer{"password1", "qx7ztk9wvb3m", "letmein123"}
+	err := Import(&in, base, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Populate the allowlist using Import.
+	allow := AllowSet{}
+	in = sliceTokenizer{"QX7ZTK9WVB3M"}
+	err = Import(&in, allow, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := NewAllowlistDB(base, allow)
+	if err := Check("qx7ztk9wvb3m", db, nil); err != nil {
+		t.Fatal("allowlisted password rejected:", err)
+	}
+	if err := Check("Password1", db, nil); !errors.Is(err, ErrPasswordInDB) {
+		t.Fatal("expected ErrPasswordInDB, got", err)
+	}
+
+	db.Allow = NewAllowSet(nil, "  LetMeIn123 ", "short")
+	if len(db.Allow.(AllowSet)) != 1 {
+		t.Fatal("expected 1 allowed entry, got", db.Allow)
+	}
+	errs := CheckMany([]string{"password1", "qx7ztk9wvb3m", "letmein123", "notindb12"}, db, nil)
+	if !errors.Is(errs[0], ErrPasswordInDB) || !errors.Is(errs[1], ErrPasswordInDB) || errs[2] != nil || errs[3] != nil {
+		t.Fatal("unexpected errors", errs)
+	}
+
+	// Errors from the allowlist are returned.
+	db.Allow = errDB{}
+	if err := Check("password1", db, nil); err != errLookup {
+		t.Fatal("expected lookup error, got", err)
+	}
+	if errs := CheckMany([]string{"password1"}, db, nil); errs[0] != errLookup {
+		t.Fatal("expected lookup error, got", errs[0])
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultBatchSize is the maximum number of passwords in a batch
+// of a BatchDB if no size is given.
+const DefaultBatchSize = 100
+
+// batchLookups is the maximum number of concurrent lookups made
+// by a BatchDB when the database does not implement BulkDB.
+const batchLookups = 16
+
+// BatchDB is a DB that collects concurrent lookups, and looks
+// them up in the underlying database together.
+// If the underlying database implements BulkDB, a batch is
+// looked up with a single call, and an error is returned to all
+// callers in the batch. Otherwise the passwords of a batch are looked
+// up separately, up to 16 at the same time, and each caller gets the
+// error of its own lookup.
+//
+// A batch is looked up when it is full, or when the first
+// lookup in it has waited for the maximum delay.
+// Each caller stops waiting when its own context is cancelled.
+// The lookup of a batch is only cancelled when all callers
+// waiting for it have stopped waiting.
+//
+// A BatchDB is safe for concurrent use.
+type BatchDB struct {
+	db       DB
+	maxDelay time.Duration
+	maxBatch int
+	mu       sync.Mutex
+	cur      *batch // Batch collecting lookups.
+}
+
+// batch is a set of passwords looked up together.
+type batch struct {
+	keys    []string
+	index   map[string]int // Index of each password in keys.
+	timer   *time.Timer
+	ctx     context.Context
+	cancel  context.CancelFunc
+	waiters int
+	done    chan struct{} // Closed when found and errs are set.
+	found   []bool
+	errs    []error // Error of each password.
+}
+
+// NewBatchDB returns a DB that collects lookups in db into batches.
+// A batch is looked up when it has maxBatch passwords,
+// or maxDelay after the first password was added to it.
+// If maxBatch is 0, DefaultBatchSize is used.
+func NewBatchDB(db DB, maxDelay time.Duration, maxBatch int) *BatchDB {
+	if maxBatch <= 0 {
+		maxBatch = DefaultBatchSize
+	}
+	return &BatchDB{db: db, maxDelay: maxDelay, maxBatch: maxBatch}
+}
+
+// Has satisfies the DB interface.
+func (b *BatchDB) Has(s string) (bool, error) {
+	return b.HasContext(context.Background(), s)
+}
+
+// HasContext satisfies the DBContext interface.
+func (b *BatchDB) HasContext(ctx context.Context, s string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	b.mu.Lock()
+	bt := b.cur
+	if bt == nil {
+		bt = &batch{index: make(map[string]int), done: make(chan struct{})}
+		bt.ctx, bt.cancel = context.WithCancel(context.Background())
+		bt.timer = time.AfterFunc(b.maxDelay, func() { b.flush(bt) })
+		b.cur = bt
+	}
+	i, ok := bt.index[s]
+	if !ok {
+		i = len(bt.keys)
+		bt.index[s] = i
+		bt.keys = append(bt.keys, s)
+	}
+	bt.waiters++
+	full := len(bt.keys) >= b.maxBatch
+	if full {
+		bt.timer.Stop()
+		b.cur = nil
+	}
+	b.mu.Unlock()
+	if full {
+		go b.run(bt)
+	}
+
+	select {
+	case <-bt.done:
+		if bt.errs[i] != nil {
+			return false, bt.errs[i]
+		}
+		return bt.found[i], nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		bt.waiters--
+		// A batch that is still collecting lookups may get new waiters.
+		if bt.waiters == 0 && b.cur != bt {
+			bt.cancel()
+		}
+		b.mu.Unlock()
+		return false, ctx.Err()
+	}
+}
+
+// flush looks up bt, if it is still collecting lookups.
+func (b *BatchDB) flush(bt *batch) {
+	b.mu.Lock()
+	if b.cur != bt {
+		b.mu.Unlock()
+		return
+	}
+	b.cur = nil
+	b.mu.Unlock()
+	b.run(bt)
+}
+
+// run looks up the passwords of bt.
+func (b *BatchDB) run(bt *batch) {
+	defer bt.cancel()
+	b.mu.Lock()
+	if bt.waiters == 0 {
+		// All callers stopped waiting before the lookup.
+		bt.cancel()
+	}
+	b.mu.Unlock()
+	bt.found, bt.errs = b.lookup(bt.ctx, bt.keys)
+	close(bt.done)
+}
+
+// lookup looks up keys in the database, and returns
+// the result and error of each key.
+func (b *BatchDB) lookup(ctx context.Context, keys []string) ([]bool, []error) {
+	errs := make([]error, len(keys))
+	switch b.db.(type) {
+	case BulkDB, BulkDBContext:
+		found, err := hasMultiple(ctx, b.db, keys)
+		if err != nil {
+			for i := range errs {
+				errs[i] = err
+			}
+		}
+		return found, errs
+	}
+	found := make([]bool, len(keys))
+	workers := batchLookups
+	if workers > len(keys) {
+		workers = len(keys)
+	}
+	next := make(chan int, len(keys))
+	for i := range keys {
+		next <- i
+	}
+	close(next)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				found[i], errs[i] = has(ctx, b.db, keys[i])
+			}
+		}()
+	}
+	wg.Wait()
+	return found, errs
+}
@@ -0,0 +1,191 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/klauspost/password/drivers/testdb"
+)
+
+// batchRecorder records the batches looked up in a database.
+type batchRecorder struct {
+	DB
+	mu      sync.Mutex
+	batches [][]string
+}
+
+func (r *batchRecorder) HasMultiple(s []string) ([]bool, error) {
+	r.mu.Lock()
+	r.batches = append(r.batches, s)
+	r.mu.Unlock()
+	return r.DB.(BulkDB).HasMultiple(s)
+}
+
+// waitForBatch blocks until n callers are waiting for the current batch.
+func waitForBatch(b *BatchDB, n int) {
+	for {
+		b.mu.Lock()
+		ok := b.cur != nil && b.cur.waiters == n
+		b.mu.Unlock()
+		if ok {
+			return
+		}
+		runtime.Gosched()
+	}
+}
+
+func TestBatchDB(t *testing.T) {
+	mem := testdb.NewMemDBBulk()
+	mem.Add("password1")
+	rec := &batchRecorder{DB: mem}
+	// Only full batches are looked up.
+	db := NewBatchDB(rec, time.Hour, 3)
+
+	passwords := []string{"password1", "notindb12", "password1", "letmein123"}
+	errs := make([]error, len(passwords))
+	var wg sync.WaitGroup
+	for i, p := range passwords {
+		// Make sure the duplicate is added before the batch is full.
+		if i == len(passwords)-1 {
+			waitForBatch(db, i)
+		}
+		wg.Add(1)
+		go func(i int, p string) {
+			defer wg.Done()
+			errs[i] = Check(p, db, nil)
+		}(i, p)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if passwords[i] == "password1" {
+			if !errors.Is(err, ErrPasswordInDB) {
+				t.Fatal("expected ErrPasswordInDB, got", err)
+			}
+		} else if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(rec.batches) != 1 || len(rec.batches[0]) != 3 {
+		t.Fatal("expected a single batch of 3, got", rec.batches)
+	}
+
+	// A batch is looked up after the delay.
+	db = NewBatchDB(rec, time.Millisecond, 0)
+	if err := Check("password1", db, nil); !errors.Is(err, ErrPasswordInDB) {
+		t.Fatal("expected ErrPasswordInDB, got", err)
+	}
+	if len(rec.batches) != 2 {
+		t.Fatal("expected 2 batches, got", rec.batches)
+	}
+}
+
+// failKeyDB is a database that fails the lookup of key.
+type failKeyDB struct {
+	DB
+	key string
+}
+
+func (f failKeyDB) Has(s string) (bool, error) {
+	if s == f.key {
+		return false, errLookup
+	}
+	return f.DB.Has(s)
+}
+
+// slowDB is a database where each lookup takes delay.
+type slowDB struct {
+	DB
+	delay time.Duration
+}
+
+func (s slowDB) Has(v string) (bool, error) {
+	time.Sleep(s.delay)
+	return s.DB.Has(v)
+}
+
+func TestBatchDBLatency(t *testing.T) {
+	// Lookups in a database without bulk lookups are made concurrently.
+	const n = 10
+	mem := testdb.NewMemDB()
+	mem.Add("password1")
+	db := NewBatchDB(slowDB{DB: mem, delay: 50 * time.Millisecond}, time.Hour, n)
+	start := time.Now()
+	var wg sync.WaitGroup
+	found := make([]bool, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			found[i], _ = db.Has(fmt.Sprintf("password%d", i))
+		}(i)
+	}
+	wg.Wait()
+	if !found[1] || found[2] {
+		t.Fatal("unexpected results", found)
+	}
+	// Sequential lookups would take 500ms.
+	if elapsed := time.Since(start); elapsed > 250*time.Millisecond {
+		t.Fatal("lookups took", elapsed)
+	}
+}
+
+func TestBatchDBErrors(t *testing.T) {
+	// All callers get the error of the batch.
+	db := NewBatchDB(errDB{}, time.Hour, 2)
+	errs := make([]error, 2)
+	var wg sync.WaitGroup
+	for i, p := range []string{"password1", "notindb12"} {
+		wg.Add(1)
+		go func(i int, p string) {
+			defer wg.Done()
+			_, errs[i] = db.Has(p)
+		}(i, p)
+	}
+	wg.Wait()
+	if errs[0] != errLookup || errs[1] != errLookup {
+		t.Fatal("expected errLookup, got", errs)
+	}
+
+	// Without bulk lookups, each caller gets its own error.
+	mem := testdb.NewMemDB()
+	mem.Add("password1")
+	db = NewBatchDB(failKeyDB{DB: mem, key: "boom12345"}, time.Hour, 3)
+	passwords := []string{"password1", "boom12345", "fine12345"}
+	found := make([]bool, len(passwords))
+	errs = make([]error, len(passwords))
+	for i, p := range passwords {
+		wg.Add(1)
+		go func(i int, p string) {
+			defer wg.Done()
+			found[i], errs[i] = db.Has(p)
+		}(i, p)
+	}
+	wg.Wait()
+	if !found[0] || errs[0] != nil || found[2] || errs[2] != nil {
+		t.Fatal("unexpected results", found, errs)
+	}
+	if errs[1] != errLookup {
+		t.Fatal("expected errLookup, got", errs[1])
+	}
+
+	// A cancelled caller gets its own error.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	_, err := db.HasContext(ctx, "password1")
+	if err != context.DeadlineExceeded {
+		t.Fatal("expected context.DeadlineExceeded, got", err)
+	}
+	db.mu.Lock()
+	waiters := db.cur.waiters
+	db.mu.Unlock()
+	if waiters != 0 {
+		t.Fatal("expected no waiters, got", waiters)
+	}
+}
@@ -2,82 +2,210 @@
 
 package password
 
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
 // If your DbWriter implements this, input will be sent
 // in batches instead of using Add.
 type BulkWriter interface {
 	AddMultiple([]string) error
 }
 
+// A BulkWriterContext is a BulkWriter that can abort
+// a batch when the supplied context is cancelled.
+type BulkWriterContext interface {
+	AddMultipleContext(context.Context, []string) error
+}
+
+// A BulkDB is a DB that can look up several passwords at once.
+// If your DB implements this, CheckMany will use it.
+//
+// HasMultiple must return a slice of the same length as the input,
+// where each entry indicates if the password at the same index
+// is in the database.
+type BulkDB interface {
+	HasMultiple([]string) ([]bool, error)
+}
+
+// A BulkDBContext is a BulkDB that can abort a lookup
+// when the supplied context is cancelled.
+type BulkDBContext interface {
+	HasMultipleContext(context.Context, []string) ([]bool, error)
+}
+
+// hasMultiple will look up all entries in db.
+// If db does not implement BulkDB, entries are looked up one by one.
+func hasMultiple(ctx context.Context, db DB, s []string) ([]bool, error) {
+	var res []bool
+	var err error
+	switch d := db.(type) {
+	case BulkDBContext:
+		res, err = d.HasMultipleContext(ctx, s)
+	case BulkDB:
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+		res, err = d.HasMultiple(s)
+	default:
+		res = make([]bool, len(s))
+		for i, v := range s {
+			res[i], err = has(ctx, db, v)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(res) != len(s) {
+		return nil, fmt.Errorf("HasMultiple returned %d results, expected %d", len(res), len(s))
+	}
+	return res, nil
+}
+
+// A ConcurrentBulkWriter is a BulkWriter that can receive several
+// batches at the same time from different goroutines.
+// If ConcurrentSafe returns true, ImportOptions.Writers batches
+// may be sent to AddMultiple at once.
+type ConcurrentBulkWriter interface {
+	BulkWriter
+	ConcurrentSafe() bool
+}
+
 type bulkWrapper struct {
-	out BulkWriter
-	res chan error
-	in  chan []string
-	buf []string
+	ctx    context.Context
+	cancel context.CancelFunc
+	out    BulkWriter
+	in     chan []string
+	buf    []string
+	size   int // Batch size
+	n      int // Number of concurrent batches
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	err    error         // First error returned by the writer
+	failed chan struct{} // Closed when err is set
 }
 
 // BulkMax is the maximum number of passwords sent at once to the writer.
 // You can change this before starting an import.
+// Use ImportOptions.BatchSize to set the size for a single import.
 var BulkMax = 1000
 
-func bulkWrap(out BulkWriter) DbWriter {
+func bulkWrap(ctx context.Context, out BulkWriter, size, n int) DbWriter {
+	ctx, cancel := context.WithCancel(ctx)
 	b := &bulkWrapper{
-		out: out,
-		res: make(chan error, 1),
-		in:  make(chan []string, 0),
-		buf: make([]string, 0, BulkMax),
+		ctx:    ctx,
+		cancel: cancel,
+		out:    out,
+		in:     make(chan []string, 0),
+		buf:    make([]string, 0, size),
+		size:   size,
+		n:      n,
+		failed: make(chan struct{}),
 	}
 	return b
 }
 
+// addMultiple will send s to out, using AddMultipleContext if out supports it.
+func addMultiple(ctx context.Context, out BulkWriter, s []string) error {
+	if w, ok := out.(BulkWriterContext); ok {
+		return w.AddMultipleContext(ctx, s)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return out.AddMultiple(s)
+}
+
 func (b *bulkWrapper) Init() error {
 	b.in = make(chan []string, 0)
-	b.res = make(chan error, 1)
-	go func() {
-		b.res <- nil
-		defer close(b.res)
-		for {
-			select {
-			case x, ok := <-b.in:
-				if !ok {
-					return
-				}
-				err := b.out.AddMultiple(x)
-				b.res <- err
+	b.wg.Add(b.n)
+	for i := 0; i < b.n; i++ {
+		go func() {
+			defer b.wg.Done()
+			for x := range b.in {
+				err := addMultiple(b.ctx, b.out, x)
 				if err != nil {
+					b.fail(err)
 					return
 				}
 			}
-		}
-	}()
+		}()
+	}
 	return nil
 }
 
+// fail will record the first error and stop all other writes.
+func (b *bulkWrapper) fail(err error) {
+	b.mu.Lock()
+	if b.err == nil {
+		b.err = err
+		close(b.failed)
+		b.cancel()
+	}
+	b.mu.Unlock()
+}
+
+// firstErr returns the first error returned by the writer, if any.
+func (b *bulkWrapper) firstErr() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.err
+}
+
+// send will wait for a writer to be ready and
+// send the current buffer to it.
+func (b *bulkWrapper) send() error {
+	select {
+	case b.in <- b.buf:
+		return nil
+	case <-b.failed:
+		return b.firstErr()
+	case <-b.ctx.Done():
+		if err := b.firstErr(); err != nil {
+			return err
+		}
+		return b.ctx.Err()
+	}
+}
+
 func (b *bulkWrapper) Add(s string) error {
+	if err := b.firstErr(); err != nil {
+		return err
+	}
 	b.buf = append(b.buf, s)
-	if len(b.buf) >= BulkMax {
-		// Get last result
-		last := <-b.res
-		if last != nil {
-			return last
+	if len(b.buf) >= b.size {
+		err := b.send()
+		if err != nil {
+			return err
 		}
-		// Send next
-		b.in <- b.buf
 
 		// Create new
-		b.buf = make([]string, 0, BulkMax)
+		b.buf = make([]string, 0, b.size)
 	}
 	return nil
 }
 
+// Close will flush remaining entries and wait for the writers to finish.
+// If the context has been cancelled, remaining entries are discarded.
 func (b *bulkWrapper) Close() error {
-	if len(b.buf) > 0 {
-		last := <-b.res
-		if last != nil {
-			return last
-		}
-		// Send next
-		b.in <- b.buf
+	if len(b.buf) > 0 && b.firstErr() == nil && b.ctx.Err() == nil {
+		b.send()
+		b.buf = nil
 	}
 	close(b.in)
-	return <-b.res
+
+	// Wait for the writers to exit.
+	b.wg.Wait()
+	err := b.firstErr()
+	if err == nil {
+		err = b.ctx.Err()
+	}
+	b.cancel()
+	return err
 }
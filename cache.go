@@ -0,0 +1,205 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"container/list"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"sync"
+	"time"
+)
+
+// DefaultCacheSize is the number of entries kept by a CachedDB
+// if no size is given.
+const DefaultCacheSize = 10000
+
+// CachedDB is a DB that keeps the results of recent lookups
+// in a bounded LRU cache, so repeated checks of the same password
+// do not reach the underlying database.
+//
+// Positive and negative results are kept for separate durations.
+// Errors are never cached.
+//
+// Passwords are not stored in the cache. Entries are keyed by an
+// HMAC of the password, with a random key generated when the
+// cache is created.
+//
+// A CachedDB is safe for concurrent use.
+type CachedDB struct {
+	db       DB
+	size     int
+	posTTL   time.Duration
+	negTTL   time.Duration
+	hmacKey  []byte
+	now      func() time.Time
+	mu       sync.Mutex
+	lru      *list.List // Most recently used first.
+	elements map[cacheKey]*list.Element
+}
+
+// cacheKey is a truncated HMAC of a password.
+type cacheKey [16]byte
+
+type cacheEntry struct {
+	key     cacheKey
+	found   bool
+	expires time.Time
+}
+
+// NewCachedDB returns a DB that caches lookups in db.
+// At most size entries are kept. If size is 0, DefaultCacheSize is used.
+// Passwords found in db are cached for positiveTTL,
+// and passwords not found are cached for negativeTTL.
+// A TTL of 0 disables caching of that kind of result.
+func NewCachedDB(db DB, size int, positiveTTL, negativeTTL time.Duration) *CachedDB {
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+	key := make([]byte, sha256.Size)
+	if _, err := rand.Read(key); err != nil {
+		panic(err)
+	}
+	return &CachedDB{
+		db:       db,
+		size:     size,
+		posTTL:   positiveTTL,
+		negTTL:   negativeTTL,
+		hmacKey:  key,
+		now:      time.Now,
+		lru:      list.New(),
+		elements: make(map[cacheKey]*list.Element, size),
+	}
+}
+
+// Has satisfies the DB interface.
+func (c *CachedDB) Has(s string) (bool, error) {
+	return c.HasContext(context.Background(), s)
+}
+
+// HasContext satisfies the DBContext interface.
+// The context is forwarded to the underlying database on a cache miss.
+func (c *CachedDB) HasContext(ctx context.Context, s string) (bool, error) {
+	key := c.key(s)
+	if found, ok := c.get(key); ok {
+		return found, nil
+	}
+	found, err := has(ctx, c.db, s)
+	if err != nil {
+		return false, err
+	}
+	c.set(key, found)
+	return found, nil
+}
+
+// HasMultiple satisfies the BulkDB interface.
+func (c *CachedDB) HasMultiple(s []string) ([]bool, error) {
+	return c.HasMultipleContext(context.Background(), s)
+}
+
+// HasMultipleContext satisfies the BulkDBContext interface.
+// Passwords not in the cache are looked up in a single
+// call to the underlying database.
+func (c *CachedDB) HasMultipleContext(ctx context.Context, s []string) ([]bool, error) {
+	res := make([]bool, len(s))
+	keys := make([]cacheKey, len(s))
+	var lookup []string
+	var index []int
+	for i, v := range s {
+		keys[i] = c.key(v)
+		found, ok := c.get(keys[i])
+		if ok {
+			res[i] = found
+			continue
+		}
+		lookup = append(lookup, v)
+		index = append(index, i)
+	}
+	if len(lookup) == 0 {
+		return res, nil
+	}
+	found, err := hasMultiple(ctx, c.db, lookup)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range index {
+		res[i] = found[j]
+		c.set(keys[i], found[j])
+	}
+	return res, nil
+}
+
+// Len returns the number of entries in the cache,
+// including expired entries that have not been evicted yet.
+func (c *CachedDB) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Purge removes all entries from the cache.
+// This should be called if the underlying database is modified.
+func (c *CachedDB) Purge() {
+	c.mu.Lock()
+	c.lru.Init()
+	c.elements = make(map[cacheKey]*list.Element, c.size)
+	c.mu.Unlock()
+}
+
+// key returns the cache key of s.
+func (c *CachedDB) key(s string) cacheKey {
+	h := hmac.New(sha256.New, c.hmacKey)
+	h.Write([]byte(s))
+	var k cacheKey
+	copy(k[:], h.Sum(nil))
+	return k
+}
+
+// get returns the cached result of key.
+// If the entry is missing or has expired, ok is false.
+func (c *CachedDB) get(key cacheKey) (found, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.elements[key]
+	if !ok {
+		return false, false
+	}
+	entry := e.Value.(*cacheEntry)
+	if !c.now().Before(entry.expires) {
+		c.lru.Remove(e)
+		delete(c.elements, key)
+		return false, false
+	}
+	c.lru.MoveToFront(e)
+	return entry.found, true
+}
+
+// set stores a result, evicting the least recently used
+// entry if the cache is full.
+func (c *CachedDB) set(key cacheKey, found bool) {
+	ttl := c.negTTL
+	if found {
+		ttl = c.posTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expires := c.now().Add(ttl)
+	if e, ok := c.elements[key]; ok {
+		entry := e.Value.(*cacheEntry)
+		entry.found = found
+		entry.expires = expires
+		c.lru.MoveToFront(e)
+		return
+	}
+	c.elements[key] = c.lru.PushFront(&cacheEntry{key: key, found: found, expires: expires})
+	if c.lru.Len() > c.size {
+		e := c.lru.Back()
+		c.lru.Remove(e)
+		delete(c.elements, e.Value.(*cacheEntry).key)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/klauspost/password/drivers/testdb"
+)
+
+// lookupCounter counts the calls to Has of a database.
+type lookupCounter struct {
+	DB
+	n int
+}
+
+func (c *lookupCounter) Has(s string) (bool, error) {
+	c.n++
+	return c.DB.Has(s)
+}
+
+func TestCachedDB(t *testing.T) {
+	mem := testdb.NewMemDB()
+	mem.Add("password1")
+	mem.Add("letmein123")
+	counter := &lookupCounter{DB: mem}
+	db := NewCachedDB(counter, 2, time.Hour, time.Minute)
+	now := time.Now()
+	db.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if err := Check("password1", db, nil); !errors.Is(err, ErrPasswordInDB) {
+			t.Fatal("expected ErrPasswordInDB, got", err)
+		}
+		if err := Check("notindb12", db, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if counter.n != 2 {
+		t.Fatal("expected 2 lookups, got", counter.n)
+	}
+
+	// Negative results expire first.
+	now = now.Add(2 * time.Minute)
+	db.Has("password1")
+	db.Has("notindb12")
+	if counter.n != 3 {
+		t.Fatal("expected 3 lookups, got", counter.n)
+	}
+
+	// "password1" is least recently used, and is evicted.
+	db.Has("letmein123")
+	if db.Len() != 2 {
+		t.Fatal("expected 2 entries, got", db.Len())
+	}
+	db.Has("password1")
+	if counter.n != 5 {
+		t.Fatal("expected 5 lookups, got", counter.n)
+	}
+
+	// Bulk lookups only look up misses.
+	errs := CheckMany([]string{"password1", "letmein123", "notindb12"}, db, nil)
+	if !errors.Is(errs[0], ErrPasswordInDB) || !errors.Is(errs[1], ErrPasswordInDB) || errs[2] != nil {
+		t.Fatal("unexpected errors", errs)
+	}
+	if counter.n != 6 {
+		t.Fatal("expected 6 lookups, got", counter.n)
+	}
+
+	db.Purge()
+	if db.Len() != 0 {
+		t.Fatal("expected empty cache, got", db.Len())
+	}
+}
+
+func TestCachedDBErrors(t *testing.T) {
+	// Errors are not cached.
+	db := NewCachedDB(errDB{}, 0, time.Hour, time.Hour)
+	for i := 0; i < 2; i++ {
+		if err := Check("password1", db, nil); err != errLookup {
+			t.Fatal("expected errLookup, got", err)
+		}
+	}
+	if db.Len() != 0 {
+		t.Fatal("expected empty cache, got", db.Len())
+	}
+
+	// Negative results are not cached with a TTL of 0.
+	db = NewCachedDB(testdb.NewMemDB(), 0, time.Hour, 0)
+	db.Has("notindb12")
+	if db.Len() != 0 {
+		t.Fatal("expected empty cache, got", db.Len())
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import "strings"
+
+// SanitizerFunc is an adapter to allow the use of ordinary
+// functions as a Sanitizer.
+type SanitizerFunc func(string) (string, error)
+
+// Sanitize calls f(s).
+func (f SanitizerFunc) Sanitize(s string) (string, error) {
+	return f(s)
+}
+
+// SanitizerChain will run several sanitizers in order.
+// The output of each sanitizer is used as input to the next.
+type SanitizerChain struct {
+	Sanitizers []Sanitizer
+
+	// CollectAll will run all sanitizers, even if one fails.
+	// If a sanitizer fails, the next sanitizer will receive the
+	// output of the last successful one.
+	// If more than one fails, the errors are returned as SanitizeErrors.
+	CollectAll bool
+}
+
+// Chain returns a sanitizer that will run the supplied sanitizers in order,
+// and stop at the first error.
+//
+// Typically the first sanitizer will be DefaultSanitizer, followed by
+// validators, for example:
+//
+//	san := Chain(DefaultSanitizer, MinDistinctRunes(5), ForbidRunes("<>"))
+//
+// Set CollectAll on the returned chain to get all errors.
+func Chain(s ...Sanitizer) *SanitizerChain {
+	return &SanitizerChain{Sanitizers: s}
+}
+
+// Sanitize runs the sanitizers of the chain.
+func (c *SanitizerChain) Sanitize(in string) (string, error) {
+	var errs SanitizeErrors
+	for _, s := range c.Sanitizers {
+		out, err := s.Sanitize(in)
+		if err != nil {
+			if !c.CollectAll {
+				return "", err
+			}
+			errs = append(errs, err)
+			continue
+		}
+		in = out
+	}
+	switch len(errs) {
+	case 0:
+		return in, nil
+	case 1:
+		return "", errs[0]
+	}
+	return "", errs
+}
+
+// SanitizeErrors is returned by a SanitizerChain with CollectAll set,
+// when more than one sanitizer fails.
+// errors.Is and errors.As will check all contained errors.
+type SanitizeErrors []error
+
+// Error returns the messages of all errors.
+func (e SanitizeErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap returns the contained errors.
+func (e SanitizeErrors) Unwrap() []error {
+	return e
+}
@@ -0,0 +1,119 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+)
+
+func TestValidators(t *testing.T) {
+	tests := []struct {
+		san Sanitizer
+		in  string
+		err error
+	}{
+		{san: MinRunes(4), in: "abcd"},
+		{san: MinRunes(4), in: "abc", err: ErrSanitizeTooShort},
+		{san: MaxRunes(4), in: "æøåæ"},
+		{san: MaxRunes(4), in: "æøåæø", err: ErrSanitizeTooLong},
+		{san: ForbidRunes("<>"), in: "password"},
+		{san: ForbidRunes("<>"), in: "pass>word", err: ErrForbiddenRune},
+		{san: DenyRegexp(regexp.MustCompile(`^\d+$`)), in: "1234a"},
+		{san: DenyRegexp(regexp.MustCompile(`^\d+$`)), in: "12345", err: ErrDenied},
+		{san: MinDistinctRunes(4), in: "abcabcd"},
+		{san: MinDistinctRunes(4), in: "abcabcabc", err: ErrTooFewDistinct},
+		{san: MinDistinctRunes(0), in: ""},
+		{san: RejectInvisible(), in: "pass word æøå 😀"},
+		{san: RejectInvisible(), in: "pass\u200dword", err: ErrInvisible},
+		{san: RejectInvisible(), in: "family\U0001f468\u200d\U0001f469\u200d\U0001f467pass"},
+		{san: RejectInvisible(), in: "\u2764\ufe0f\u200d\U0001f525 \U0001f44b\U0001f3fd\u200d\u2642\ufe0f"},
+		{san: RejectInvisible(), in: "flag \U0001f3f4\U000e0067\U000e0062\U000e0073\U000e0063\U000e0074\U000e007f"},
+		{san: RejectInvisible(), in: "\U0001f468\u200dpass", err: ErrInvisible},
+		{san: RejectInvisible(), in: "pass\U000e0067", err: ErrInvisible},
+		{san: RejectInvisible(), in: "pass\u00adword", err: ErrInvisible},
+		{san: RejectInvisible(), in: "pass\u202eword", err: ErrInvisible},
+		{san: RejectInvisible(), in: "pass\x07word", err: ErrInvisible},
+		{san: RejectInvisible(), in: "pass\u0085word", err: ErrInvisible},
+		{san: RejectInvisible(), in: "pass\tword", err: ErrInvisible},
+	}
+	for i, test := range tests {
+		out, err := test.san.Sanitize(test.in)
+		if !errors.Is(err, test.err) {
+			t.Fatalf("test %d: expected error %v, got %v", i, test.err, err)
+		}
+		if err == nil && out != test.in {
+			t.Fatalf("test %d: validator changed input %q to %q", i, test.in, out)
+		}
+	}
+
+	var ce *CheckError
+	_, err := ForbidRunes("<>").Sanitize("pass>word")
+	if !errors.As(err, &ce) || ce.Detail != ">" || ce.Reason != ReasonForbiddenRune {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+	_, err = RejectInvisible().Sanitize("pass\u200bword")
+	if !errors.As(err, &ce) || ce.Detail != "U+200B" || ce.Reason != ReasonInvisible {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+}
+
+func TestStripInvisible(t *testing.T) {
+	san := Chain(StripInvisible(), DefaultSanitizer)
+	tests := map[string]string{
+		"pass\u200dword":                   "password",
+		"\u202epass\u00adword":             "password",
+		"pass\x00word\ufeff":               "password",
+		"pass word":                        "pass word",
+		"family\U0001f468\u200d\U0001f469": "family\U0001f468\u200d\U0001f469",
+	}
+	for in, want := range tests {
+		out, err := san.Sanitize(in)
+		if err != nil || out != want {
+			t.Errorf("%q: expected %q, got %q, %v", in, want, out, err)
+		}
+	}
+	// Stripped characters are not counted.
+	if _, err := san.Sanitize("pass\u200d\u200d\u200d"); !errors.Is(err, ErrSanitizeTooShort) {
+		t.Fatal("expected ErrSanitizeTooShort, got", err)
+	}
+}
+
+func TestChain(t *testing.T) {
+	san := Chain(DefaultSanitizer, MinDistinctRunes(5), ForbidRunes("<"))
+	out, err := san.Sanitize("  Password1  ")
+	if err != nil || out != "Password1" {
+		t.Fatalf("unexpected result %q, %v", out, err)
+	}
+	_, err = san.Sanitize("aaaaaaaa<")
+	if !errors.Is(err, ErrTooFewDistinct) || errors.Is(err, ErrForbiddenRune) {
+		t.Fatal("expected only first error, got", err)
+	}
+
+	san.CollectAll = true
+	_, err = san.Sanitize("aaaaaaaa<")
+	if !errors.Is(err, ErrTooFewDistinct) || !errors.Is(err, ErrForbiddenRune) {
+		t.Fatal("expected both errors, got", err)
+	}
+	if len(err.(SanitizeErrors)) != 2 {
+		t.Fatal("expected 2 errors, got", err)
+	}
+	_, err = san.Sanitize("aaaaaaaaaa")
+	if _, ok := err.(SanitizeErrors); ok || !errors.Is(err, ErrTooFewDistinct) {
+		t.Fatalf("expected single error, got %#v", err)
+	}
+}
+
+// This example shows how to combine the default sanitizer
+// with validators.
+func ExampleChain() {
+	san := Chain(DefaultSanitizer, MinDistinctRunes(5), ForbidRunes("<>"))
+	san.CollectAll = true
+
+	fmt.Println(SanitizeOK("aaaaaaaa<", san))
+	fmt.Println(SanitizeOK("MyP/|$$W0rd", san))
+	// Output: password has too few different characters; password contains a forbidden character
+	// <nil>
+}
@@ -0,0 +1,103 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import "errors"
+
+// ErrTooSimilar is returned by CheckChange if the new password
+// is too similar to the old password.
+var ErrTooSimilar = errors.New("password is too similar to the previous password")
+
+// ChangePolicy controls how similar a new password may be to
+// the password it replaces.
+//
+// Passwords are compared case insensitively after sanitizing.
+// Rejected passwords will return a *CheckError with ReasonTooSimilar.
+// Detail is "distance" if the edit distance is too small,
+// with the distance in Actual and the limit in Max, or
+// "substring" if the passwords share a too long substring,
+// with the length in Actual and the limit in Max.
+type ChangePolicy struct {
+	// Sanitizer applied to both passwords.
+	// If nil, DefaultSanitizer will be used.
+	Sanitizer Sanitizer
+
+	// MaxDistance is the maximum edit distance between the passwords,
+	// for the new password to be rejected.
+	// If 0, 3 is used. Use a negative value to disable the check.
+	MaxDistance int
+
+	// MaxCommonRunes is the length of the longest substring the passwords
+	// may share. If 0, half the length of the new password is used,
+	// but at least 4. Use a negative value to disable the check.
+	MaxCommonRunes int
+
+	// Confusables will fold common homoglyphs of Latin letters in
+	// both passwords before they are compared, so a new password
+	// that only replaces letters with letters that look the same
+	// is rejected. See FoldHomoglyphs.
+	Confusables bool
+
+	// Folding is used to fold the case of both passwords
+	// before they are compared. If nil, FoldLower is used.
+	Folding Folding
+}
+
+// CheckChange will check that the new password is not
+// too similar to the old password, using the default ChangePolicy
+// with the supplied Sanitizer.
+// If nil is passed as Sanitizer, DefaultSanitizer will be used.
+func CheckChange(old, new string, san Sanitizer) error {
+	return ChangePolicy{Sanitizer: san}.Check(old, new)
+}
+
+// Check will check that the new password is not too similar to the old.
+// It will return an error if:
+//   - Sanitazition of the new password fails.
+//   - The new password is too similar to the old (ErrTooSimilar).
+//
+// If the old password does not pass the sanitizer, for instance because
+// the requirements have changed, it is compared without sanitizing.
+func (c ChangePolicy) Check(old, new string) error {
+	san := c.Sanitizer
+	if san == nil {
+		san = DefaultSanitizer
+	}
+	n, err := san.Sanitize(new)
+	if err != nil {
+		return err
+	}
+	o, err := san.Sanitize(old)
+	if err != nil {
+		o = old
+	}
+	nr := []rune(dbKey(n, c.Folding, c.Confusables))
+	or := []rune(dbKey(o, c.Folding, c.Confusables))
+
+	maxDist := c.MaxDistance
+	if maxDist == 0 {
+		maxDist = 3
+	}
+	if maxDist > 0 {
+		// The distance is at least the difference in length.
+		if d := len(nr) - len(or); d <= maxDist && d >= -maxDist {
+			if dist := levenshtein(nr, or); dist <= maxDist {
+				return &CheckError{Reason: ReasonTooSimilar, Detail: "distance", Max: maxDist, Actual: dist, Err: ErrTooSimilar}
+			}
+		}
+	}
+
+	maxCommon := c.MaxCommonRunes
+	if maxCommon == 0 {
+		maxCommon = len(nr) / 2
+		if maxCommon < 4 {
+			maxCommon = 4
+		}
+	}
+	if maxCommon > 0 {
+		if common := longestCommonSubstring(nr, or); common > maxCommon {
+			return &CheckError{Reason: ReasonTooSimilar, Detail: "substring", Max: maxCommon, Actual: common, Err: ErrTooSimilar}
+		}
+	}
+	return nil
+}
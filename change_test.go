@@ -0,0 +1,75 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLongestCommonSubstring(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 0},
+		{"winter2023!", "winter2024!", 9},
+		{"abcdef", "zzcdezz", 3},
+		{"æøå", "øå", 2},
+	}
+	for _, test := range tests {
+		if got := longestCommonSubstring([]rune(test.a), []rune(test.b)); got != test.want {
+			t.Errorf("%q, %q: expected %d, got %d", test.a, test.b, test.want, got)
+		}
+	}
+}
+
+func TestCheckChange(t *testing.T) {
+	tests := []struct {
+		old, new string
+		detail   string
+	}{
+		{old: "Winter2023!", new: "Winter2024!", detail: "distance"},
+		{old: "Winter2023!", new: "winter2023!", detail: "distance"},
+		{old: "Winter2023!", new: "NewWinter2023", detail: "substring"},
+		{old: "Winter2023!", new: "Summer2024!"},
+		{old: "correct horse battery", new: "correct horse staple", detail: "substring"},
+		{old: "Winter2023!", new: "correct horse battery staple"},
+		{old: "Winter2023!", new: "MyP/|$$W0rd"},
+		{old: "short", new: "shorter1", detail: "distance"},
+	}
+	for _, test := range tests {
+		err := CheckChange(test.old, test.new, nil)
+		if test.detail == "" {
+			if err != nil {
+				t.Errorf("%q -> %q: unexpected error %v", test.old, test.new, err)
+			}
+			continue
+		}
+		var ce *CheckError
+		if !errors.As(err, &ce) || !errors.Is(err, ErrTooSimilar) || ce.Reason != ReasonTooSimilar {
+			t.Errorf("%q -> %q: expected ErrTooSimilar, got %v", test.old, test.new, err)
+			continue
+		}
+		if ce.Detail != test.detail {
+			t.Errorf("%q -> %q: expected %s, got %s", test.old, test.new, test.detail, ce.Detail)
+		}
+	}
+
+	if err := CheckChange("Winter2023!", "short", nil); !errors.Is(err, ErrSanitizeTooShort) {
+		t.Fatal("expected ErrSanitizeTooShort, got", err)
+	}
+
+	// Disable both checks.
+	c := ChangePolicy{MaxDistance: -1, MaxCommonRunes: -1}
+	if err := c.Check("Winter2023!", "Winter2024!"); err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	c = ChangePolicy{MaxDistance: -1, MaxCommonRunes: 8}
+	err := c.Check("Winter2023!", "Winter2024!")
+	var ce *CheckError
+	if !errors.As(err, &ce) || ce.Detail != "substring" || ce.Actual != 9 || ce.Max != 8 {
+		t.Fatalf("unexpected error %#v", err)
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"context"
+	"sort"
+	"unicode/utf8"
+)
+
+// A VariantGenerator returns variants of a password that
+// should also be looked up in the database.
+//
+// The input is the sanitized, lowercase password.
+// The returned variants should also be lowercase.
+// The input itself should not be returned.
+type VariantGenerator interface {
+	Variants(s string) []string
+}
+
+// CheckOptions can be used to control a check.
+// The zero value will give the same behaviour as Check.
+type CheckOptions struct {
+	// Sanitizer used to clean up the password.
+	// If nil, DefaultSanitizer will be used.
+	Sanitizer Sanitizer
+
+	// Variants are used to generate variants of the password
+	// that are also looked up.
+	// Each generator is applied to the password and to all
+	// variants returned by the previous generators.
+	// Shorter values, like words with affixes removed, are expanded
+	// first, so they are not crowded out by variants of the full
+	// password when MaxLookups is reached.
+	Variants []VariantGenerator
+
+	// MaxLookups is the maximum number of values looked up,
+	// including the password itself. If 0, 32 is used.
+	// If the database implements BulkDB, all values are looked
+	// up at once.
+	MaxLookups int
+
+	// Confusables will fold common homoglyphs of Latin letters in
+	// the password, so passwords that look the same are treated as
+	// the same. See FoldHomoglyphs. The database must be imported with
+	// ImportOptions.Confusables set for this to be effective.
+	Confusables bool
+
+	// Folding is used to fold the case of the password.
+	// It must match the Folding used when importing the database.
+	// If nil, FoldLower is used.
+	Folding Folding
+}
+
+// CheckWithOptions will check a password against the database,
+// like Check, but with settings supplied in opts.
+//
+// If a variant of the password is found, the returned *CheckError
+// will have Variant set to the value found in the database.
+func CheckWithOptions(password string, db DB, opts CheckOptions) error {
+	return CheckWithOptionsContext(context.Background(), password, db, opts)
+}
+
+// CheckWithOptionsContext is the same as CheckWithOptions,
+// but the lookup can be cancelled using the supplied context.
+func CheckWithOptionsContext(ctx context.Context, password string, db DB, opts CheckOptions) error {
+	san := opts.Sanitizer
+	if san == nil {
+		san = DefaultSanitizer
+	}
+	p, err := san.Sanitize(password)
+	if err != nil {
+		return err
+	}
+	p = dbKey(p, opts.Folding, opts.Confusables)
+	keys := opts.lookups(p)
+	var found []bool
+	if len(keys) == 1 {
+		found = make([]bool, 1)
+		found[0], err = has(ctx, db, p)
+	} else {
+		found, err = hasMultiple(ctx, db, keys)
+	}
+	if err != nil {
+		return err
+	}
+	return foundError(p, keys, found)
+}
+
+// CheckManyWithOptions will check several passwords against the database,
+// like CheckMany, but with settings supplied in opts.
+// MaxLookups applies to each password.
+func CheckManyWithOptions(passwords []string, db DB, opts CheckOptions) []error {
+	return CheckManyWithOptionsContext(context.Background(), passwords, db, opts)
+}
+
+// CheckManyWithOptionsContext is the same as CheckManyWithOptions,
+// but the lookup can be cancelled using the supplied context.
+func CheckManyWithOptionsContext(ctx context.Context, passwords []string, db DB, opts CheckOptions) []error {
+	san := opts.Sanitizer
+	if san == nil {
+		san = DefaultSanitizer
+	}
+	errs := make([]error, len(passwords))
+	normalized := make([]string, len(passwords))
+	lookups := make([][]int, len(passwords)) // Index in keys of each lookup.
+	var keys []string
+	index := make(map[string]int, len(passwords))
+	for i, password := range passwords {
+		p, err := san.Sanitize(password)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		p = dbKey(p, opts.Folding, opts.Confusables)
+		normalized[i] = p
+		for _, k := range opts.lookups(p) {
+			j, ok := index[k]
+			if !ok {
+				j = len(keys)
+				index[k] = j
+				keys = append(keys, k)
+			}
+			lookups[i] = append(lookups[i], j)
+		}
+	}
+	if len(keys) == 0 {
+		return errs
+	}
+	found, err := hasMultiple(ctx, db, keys)
+	for i, l := range lookups {
+		if len(l) == 0 {
+			continue
+		}
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		k := make([]string, len(l))
+		f := make([]bool, len(l))
+		for n, j := range l {
+			k[n], f[n] = keys[j], found[j]
+		}
+		errs[i] = foundError(normalized[i], k, f)
+	}
+	return errs
+}
+
+// foundError returns the error for the first of keys found in
+// the database, or nil if none was found.
+// keys must be the lookups of the normalized password p.
+func foundError(p string, keys []string, found []bool) error {
+	for i, f := range found {
+		if !f {
+			continue
+		}
+		e := inDBError(p)
+		if i > 0 {
+			e.Variant = keys[i]
+		}
+		return e
+	}
+	return nil
+}
+
+// lookups returns the values to look up for the normalized password p.
+// p is always the first value.
+func (o CheckOptions) lookups(p string) []string {
+	max := o.MaxLookups
+	if max <= 0 {
+		max = 32
+	}
+	keys := []string{p}
+	seen := map[string]struct{}{p: {}}
+	for _, g := range o.Variants {
+		expand := append([]string{}, keys...)
+		sort.SliceStable(expand, func(i, j int) bool {
+			return utf8.RuneCountInString(expand[i]) < utf8.RuneCountInString(expand[j])
+		})
+		for _, k := range expand {
+			for _, v := range g.Variants(k) {
+				if len(keys) >= max {
+					return keys
+				}
+				if _, ok := seen[v]; ok {
+					continue
+				}
+				seen[v] = struct{}{}
+				keys = append(keys, v)
+			}
+		}
+	}
+	return keys
+}
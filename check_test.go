@@ -0,0 +1,131 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/klauspost/password/drivers/testdb"
+)
+
+func TestLeetVariants(t *testing.T) {
+	v := Leet{}.Variants("p@$$w0rd")
+	if len(v) == 0 || v[0] != "password" {
+		t.Fatal("expected first variant to be password, got", v)
+	}
+	for _, s := range v {
+		if s == "p@$$w0rd" {
+			t.Fatal("input returned as variant")
+		}
+	}
+	// 4 positions, 2 choices each, minus the original.
+	if len(v) != 15 {
+		t.Fatal("expected 15 variants, got", len(v))
+	}
+	if v := (Leet{MaxVariants: 3}).Variants("p@$$w0rd"); len(v) != 3 {
+		t.Fatal("expected 3 variants, got", v)
+	}
+	if v := (Leet{}).Variants("password"); len(v) != 0 {
+		t.Fatal("expected no variants, got", v)
+	}
+	v = Leet{Map: map[rune][]rune{'1': {'l', 'i'}}}.Variants("a1")
+	if len(v) != 2 || v[0] != "al" || v[1] != "ai" {
+		t.Fatal("unexpected variants", v)
+	}
+}
+
+func TestCheckWithOptions(t *testing.T) {
+	db := testdb.NewMemDB()
+	for _, s := range []string{"password", "password1!", "secret12"} {
+		err := db.Add(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts := CheckOptions{Variants: []VariantGenerator{Leet{}}}
+	tests := map[string]string{
+		"p4$$w0rd":   "password",
+		"P@ssw0rd1!": "password1!",
+		"P@SSWORD":   "password",
+		"5ecret12":   "secret12",
+	}
+	for pw, variant := range tests {
+		err := CheckWithOptions(pw, db, opts)
+		var ce *CheckError
+		if !errors.As(err, &ce) || !errors.Is(err, ErrPasswordInDB) {
+			t.Errorf("%q: expected ErrPasswordInDB, got %v", pw, err)
+			continue
+		}
+		if ce.Variant != variant {
+			t.Errorf("%q: expected variant %q, got %q", pw, variant, ce.Variant)
+		}
+	}
+
+	// The password itself does not set a variant.
+	err := CheckWithOptions("Password", db, opts)
+	var ce *CheckError
+	if !errors.As(err, &ce) || ce.Variant != "" || ce.Normalized != "password" {
+		t.Fatalf("unexpected error %#v", err)
+	}
+
+	for _, pw := range []string{"p4$$w0rdz", "correct horse"} {
+		if err := CheckWithOptions(pw, db, opts); err != nil {
+			t.Errorf("%q: unexpected error %v", pw, err)
+		}
+	}
+	// Without variants, only the password itself is checked.
+	if err := CheckWithOptions("p4$$w0rd", db, CheckOptions{}); err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	// Lookups are limited.
+	if err := CheckWithOptions("p4$$w0rd", db, CheckOptions{Variants: opts.Variants, MaxLookups: 1}); err != nil {
+		t.Fatal("unexpected error", err)
+	}
+}
+
+// countDB counts the number of values looked up.
+type countDB struct {
+	DB
+	n int
+}
+
+func (c *countDB) HasMultiple(s []string) ([]bool, error) {
+	c.n += len(s)
+	return make([]bool, len(s)), nil
+}
+
+func TestCheckWithLeetAndAffixes(t *testing.T) {
+	db := testdb.NewMemDB()
+	for _, s := range []string{"dragon", "password"} {
+		err := db.Add(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	orders := [][]VariantGenerator{{Leet{}, AffixStripper{}}, {AffixStripper{}, Leet{}}}
+	for _, variants := range orders {
+		opts := CheckOptions{Variants: variants}
+		for _, pw := range []string{"Dr@g0n2023!", "P@ssw0rd2024!", "dr4g0n1999"} {
+			if err := CheckWithOptions(pw, db, opts); !errors.Is(err, ErrPasswordInDB) {
+				t.Errorf("%q (%T first): expected ErrPasswordInDB, got %v", pw, variants[0], err)
+			}
+		}
+	}
+	// Digits in runs are substituted last.
+	v := Leet{}.Variants("p@ssw0rd2024")
+	if len(v) == 0 || v[0] != "password2024" {
+		t.Fatal("expected first variant to be password2024, got", v)
+	}
+}
+
+func TestCheckWithOptionsMaxLookups(t *testing.T) {
+	db := &countDB{DB: testdb.NewMemDB()}
+	err := CheckWithOptions("1!|1!|1!|1!", db, CheckOptions{Variants: []VariantGenerator{Leet{MaxVariants: 1000}}, MaxLookups: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.n != 10 {
+		t.Fatal("expected 10 lookups, got", db.n)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"context"
+	"sync"
+)
+
+// CoalescingDB is a DB that merges concurrent lookups of the
+// same password into a single lookup in the underlying database.
+// All callers waiting for the lookup get the same result and error.
+//
+// Each caller stops waiting when its own context is cancelled.
+// The shared lookup is only cancelled when all callers
+// waiting for it have stopped waiting.
+// The shared lookup does not get the values of the callers contexts.
+//
+// A CoalescingDB is safe for concurrent use.
+type CoalescingDB struct {
+	db    DB
+	mu    sync.Mutex
+	calls map[string]*coalescedCall
+}
+
+// coalescedCall is a lookup in progress.
+type coalescedCall struct {
+	done    chan struct{} // Closed when found and err are set.
+	found   bool
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// NewCoalescingDB returns a DB that coalesces concurrent
+// lookups in db.
+func NewCoalescingDB(db DB) *CoalescingDB {
+	return &CoalescingDB{db: db, calls: make(map[string]*coalescedCall)}
+}
+
+// Has satisfies the DB interface.
+func (c *CoalescingDB) Has(s string) (bool, error) {
+	return c.HasContext(context.Background(), s)
+}
+
+// HasContext satisfies the DBContext interface.
+func (c *CoalescingDB) HasContext(ctx context.Context, s string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	c.mu.Lock()
+	call, ok := c.calls[s]
+	if !ok {
+		var callCtx context.Context
+		call = &coalescedCall{done: make(chan struct{})}
+		callCtx, call.cancel = context.WithCancel(context.Background())
+		c.calls[s] = call
+		go c.run(callCtx, call, s)
+	}
+	call.waiters++
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.found, call.err
+	case <-ctx.Done():
+		c.mu.Lock()
+		call.waiters--
+		if call.waiters == 0 {
+			// Nobody is waiting, so stop the lookup,
+			// and let new callers start a new one.
+			call.cancel()
+			c.forget(call, s)
+		}
+		c.mu.Unlock()
+		return false, ctx.Err()
+	}
+}
+
+// run performs the lookup of call.
+func (c *CoalescingDB) run(ctx context.Context, call *coalescedCall, s string) {
+	defer call.cancel()
+	found, err := has(ctx, c.db, s)
+	c.mu.Lock()
+	c.forget(call, s)
+	c.mu.Unlock()
+	call.found, call.err = found, err
+	close(call.done)
+}
+
+// forget removes call from the calls in progress,
+// unless it has already been replaced.
+// c.mu must be held.
+func (c *CoalescingDB) forget(call *coalescedCall, s string) {
+	if c.calls[s] == call {
+		delete(c.calls, s)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// gateDB is a database that blocks lookups until release is closed.
+type gateDB struct {
+	release chan struct{}
+	n       int32
+	err     error
+}
+
+func (g *gateDB) Has(s string) (bool, error) {
+	panic("Has called on gateDB")
+}
+
+func (g *gateDB) HasContext(ctx context.Context, s string) (bool, error) {
+	atomic.AddInt32(&g.n, 1)
+	select {
+	case <-g.release:
+		return s == "password1", g.err
+	case <-ctx.Done():
+		return false, ctx.Err()
+	}
+}
+
+// waitFor blocks until n callers are waiting for the lookup of s.
+func waitFor(c *CoalescingDB, s string, n int) {
+	for {
+		c.mu.Lock()
+		call := c.calls[s]
+		ok := call != nil && call.waiters == n
+		c.mu.Unlock()
+		if ok {
+			return
+		}
+		runtime.Gosched()
+	}
+}
+
+func TestCoalescingDB(t *testing.T) {
+	backend := &gateDB{release: make(chan struct{})}
+	db := NewCoalescingDB(backend)
+
+	const n = 10
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = Check("password1", db, nil)
+		}(i)
+	}
+	waitFor(db, "password1", n)
+	close(backend.release)
+	wg.Wait()
+	for _, err := range errs {
+		if !errors.Is(err, ErrPasswordInDB) {
+			t.Fatal("expected ErrPasswordInDB, got", err)
+		}
+	}
+	if backend.n != 1 {
+		t.Fatal("expected 1 lookup, got", backend.n)
+	}
+
+	// Once done, new lookups reach the backend.
+	found, err := db.Has("notindb12")
+	if found || err != nil {
+		t.Fatal("unexpected result", found, err)
+	}
+	if backend.n != 2 {
+		t.Fatal("expected 2 lookups, got", backend.n)
+	}
+
+	// Errors are shared.
+	backend = &gateDB{release: make(chan struct{}), err: errLookup}
+	db = NewCoalescingDB(backend)
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = db.Has("password1")
+		}(i)
+	}
+	waitFor(db, "password1", 2)
+	close(backend.release)
+	wg.Wait()
+	if errs[0] != errLookup || errs[1] != errLookup {
+		t.Fatal("expected errLookup, got", errs[:2])
+	}
+}
+
+func TestCoalescingDBCancel(t *testing.T) {
+	backend := &gateDB{release: make(chan struct{})}
+	db := NewCoalescingDB(backend)
+
+	// A cancelled waiter does not cancel the lookup of the others.
+	ctx, cancel := context.WithCancel(context.Background())
+	var found bool
+	var err error
+	done := make(chan struct{})
+	go func() {
+		found, err = db.Has("password1")
+		close(done)
+	}()
+	waitFor(db, "password1", 1)
+	cancelled := make(chan error)
+	go func() {
+		_, err := db.HasContext(ctx, "password1")
+		cancelled <- err
+	}()
+	waitFor(db, "password1", 2)
+	cancel()
+	if err := <-cancelled; err != context.Canceled {
+		t.Fatal("expected context.Canceled, got", err)
+	}
+	close(backend.release)
+	<-done
+	if !found || err != nil {
+		t.Fatal("unexpected result", found, err)
+	}
+
+	// When all waiters are cancelled, so is the lookup.
+	backend = &gateDB{release: make(chan struct{})}
+	db = NewCoalescingDB(backend)
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		_, err := db.HasContext(ctx, "password1")
+		cancelled <- err
+	}()
+	waitFor(db, "password1", 1)
+	cancel()
+	if err := <-cancelled; err != context.Canceled {
+		t.Fatal("expected context.Canceled, got", err)
+	}
+	db.mu.Lock()
+	if len(db.calls) != 0 {
+		t.Fatal("expected no lookups in progress, got", len(db.calls))
+	}
+	db.mu.Unlock()
+}
@@ -0,0 +1,93 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// confusables maps characters to the Latin letters they
+// are visually confusable with.
+// It is a hand-picked list of Cyrillic, Greek, Armenian and Latin
+// letters, and not the full Unicode TR39 confusables table.
+var confusables = map[rune]rune{
+	// Cyrillic
+	'а': 'a', 'А': 'A',
+	'В': 'B',
+	'с': 'c', 'С': 'C',
+	'ԁ': 'd',
+	'е': 'e', 'Е': 'E',
+	'һ': 'h', 'Н': 'H',
+	'і': 'i', 'І': 'I',
+	'ј': 'j', 'Ј': 'J',
+	'К': 'K',
+	'ӏ': 'l', 'Ӏ': 'l',
+	'М': 'M',
+	'о': 'o', 'О': 'O',
+	'р': 'p', 'Р': 'P',
+	'ԛ': 'q', 'Ԛ': 'Q',
+	'ѕ': 's', 'Ѕ': 'S',
+	'Т': 'T',
+	'ԝ': 'w', 'Ԝ': 'W',
+	'х': 'x', 'Х': 'X',
+	'у': 'y', 'ү': 'y', 'Ү': 'Y',
+
+	// Greek
+	'α': 'a', 'Α': 'A',
+	'Β': 'B',
+	'ϲ': 'c', 'Ϲ': 'C',
+	'Ε': 'E',
+	'Η': 'H',
+	'ι': 'i', 'Ι': 'I',
+	'ϳ': 'j',
+	'κ': 'k', 'Κ': 'K',
+	'Μ': 'M',
+	'Ν': 'N',
+	'ο': 'o', 'Ο': 'O',
+	'ρ': 'p', 'Ρ': 'P',
+	'Τ': 'T',
+	'υ': 'u', 'Υ': 'Y',
+	'ν': 'v',
+	'χ': 'x', 'Χ': 'X',
+	'Ζ': 'Z',
+
+	// Armenian
+	'հ': 'h',
+	'ո': 'n',
+	'օ': 'o', 'Օ': 'O',
+	'զ': 'q',
+	'ս': 'u', 'Ս': 'U',
+	'ց': 'g',
+
+	// Latin
+	'ɑ': 'a',
+	'ɡ': 'g',
+	'ı': 'i', 'ɩ': 'i',
+	'ǀ': 'l',
+	'ʋ': 'u',
+}
+
+// FoldHomoglyphs replaces Cyrillic, Greek, Armenian and Latin
+// letters that look like Latin letters with the letter they look like,
+// so "pаssword" with a Cyrillic "а" will return "password".
+// The result is NFD normalized.
+//
+// This is a partial fold of common homoglyphs, similar to, but much
+// smaller than, the skeleton of Unicode TR39. Other scripts, digits
+// and symbols are not folded, so strings that look the same may
+// still be different after folding.
+//
+// The result is only intended for comparing strings,
+// and should not be shown to the user.
+func FoldHomoglyphs(s string) string {
+	s = norm.NFD.String(s)
+	s = strings.Map(func(r rune) rune {
+		if c, ok := confusables[r]; ok {
+			return c
+		}
+		return r
+	}, s)
+	return norm.NFD.String(s)
+}
@@ -0,0 +1,91 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/klauspost/password/drivers/testdb"
+)
+
+func TestFoldHomoglyphs(t *testing.T) {
+	tests := map[string]string{
+		"pаssword":           "password", // Cyrillic а
+		"pаsswоrd":           "password", // Cyrillic а and о
+		"ρassωοrd":           "passωord", // Greek ρ and ο, ω is not confusable
+		"РАSSWОRD":           "PASSWORD", // Cyrillic Р, А and О
+		"password":           "password",
+		"s\u00e9curit\u00e9": "se\u0301curite\u0301", // Decomposed
+		"ѕесrеt123":          "secret123",
+	}
+	for in, want := range tests {
+		if got := FoldHomoglyphs(in); got != want {
+			t.Errorf("FoldHomoglyphs(%q): expected %q, got %q", in, want, got)
+		}
+	}
+}
+
+func TestImportCheckConfusables(t *testing.T) {
+	db := testdb.NewMemDB()
+	in := sliceTokenizer{"pаssword", "secret12"}
+	_, err := ImportWithOptions(&in, db, ImportOptions{Confusables: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := (*db)["password"]; !ok {
+		t.Fatal("expected folded password to be stored, got", *db)
+	}
+	opts := CheckOptions{Confusables: true}
+	for _, pw := range []string{"password", "pаssword", "PАSSWОRD", "ѕесrеt12"} {
+		err := CheckWithOptions(pw, db, opts)
+		if !errors.Is(err, ErrPasswordInDB) {
+			t.Errorf("%q: expected ErrPasswordInDB, got %v", pw, err)
+		}
+	}
+	// Without confusables, the Cyrillic letters are not matched.
+	if err := CheckWithOptions("pаssword", db, CheckOptions{}); err != nil {
+		t.Fatal("unexpected error", err)
+	}
+}
+
+func TestConfusablesOptions(t *testing.T) {
+	db := testdb.NewMemDBBulk()
+	in := sliceTokenizer{"pаssword", "letmein123"}
+	_, err := ImportWithOptions(&in, db, ImportOptions{Confusables: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := CheckOptions{Confusables: true, Variants: []VariantGenerator{Leet{}}}
+	errs := CheckManyWithOptions([]string{"pаssword", "p@ssw0rd", "short", "lеtmеin123", "notindb12"}, db, opts)
+	if !errors.Is(errs[0], ErrPasswordInDB) || !errors.Is(errs[1], ErrPasswordInDB) || !errors.Is(errs[2], ErrSanitizeTooShort) ||
+		!errors.Is(errs[3], ErrPasswordInDB) || errs[4] != nil {
+		t.Fatal("unexpected errors", errs)
+	}
+	var ce *CheckError
+	if !errors.As(errs[1], &ce) || ce.Normalized != "p@ssw0rd" || ce.Variant != "password" {
+		t.Fatalf("unexpected error %#v", errs[1])
+	}
+	// Without confusables, the Cyrillic letters are not matched.
+	if errs := CheckMany([]string{"lеtmеin123"}, db, nil); errs[0] != nil {
+		t.Fatal("unexpected error", errs[0])
+	}
+
+	allow := NewAllowSetWithOptions(ImportOptions{Confusables: true}, "LЕTMЕIN123")
+	if err := Check("letmein123", NewAllowlistDB(db, allow), nil); err != nil {
+		t.Fatal("allowlisted password rejected:", err)
+	}
+	if _, ok := NewAllowSet(nil, "LЕTMЕIN123")["letmein123"]; ok {
+		t.Fatal("homoglyphs folded without Confusables")
+	}
+
+	// The new password only replaces letters with Cyrillic letters.
+	c := ChangePolicy{Confusables: true}
+	if err := c.Check("correcthorse", "соrrесthоrsе"); !errors.Is(err, ErrTooSimilar) {
+		t.Fatal("expected ErrTooSimilar, got", err)
+	}
+	if err := CheckChange("correcthorse", "соrrесthоrsе", nil); err != nil {
+		t.Fatal("unexpected error", err)
+	}
+}
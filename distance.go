@@ -0,0 +1,69 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+// levenshtein returns the edit distance between a and b,
+// counting insertions, deletions and substitutions of runes.
+func levenshtein(a, b []rune) int {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// reverse returns the runes of s in reverse order.
+func reverse(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
+
+// longestCommonSubstring returns the length of the longest
+// run of runes found in both a and b.
+func longestCommonSubstring(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	best := 0
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			if a[i-1] != b[j-1] {
+				cur[j] = 0
+				continue
+			}
+			cur[j] = prev[j-1] + 1
+			if cur[j] > best {
+				best = cur[j]
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return best
+}
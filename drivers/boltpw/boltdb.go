@@ -4,6 +4,8 @@
 package boltpw
 
 import (
+	"context"
+
 	"github.com/boltdb/bolt"
 )
 
@@ -44,6 +46,15 @@ func (b BoltDB) Has(s string) (bool, error) {
 	return res, err
 }
 
+// HasContext satisfies the password.DBContext interface.
+// Since lookups are local, the context is only checked before the lookup.
+func (b BoltDB) HasContext(ctx context.Context, s string) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return b.Has(s)
+}
+
 // Has satisfies the password.DbWriter interface.
 // It writes a single password to the database
 func (b BoltDB) Add(s string) error {
@@ -54,12 +65,31 @@ func (b BoltDB) Add(s string) error {
 	})
 }
 
+// AddContext satisfies the password.DbWriterContext interface.
+// The context is checked before the write.
+func (b BoltDB) AddContext(ctx context.Context, s string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return b.Add(s)
+}
+
 // AddMultiple satisfies the password.BulkWriter interface.
 // It writes a number of passwords to the database
 func (b BoltDB) AddMultiple(s []string) error {
+	return b.AddMultipleContext(context.Background(), s)
+}
+
+// AddMultipleContext satisfies the password.BulkWriterContext interface.
+// If the context is cancelled during the write, the transaction is
+// rolled back and the context error is returned.
+func (b BoltDB) AddMultipleContext(ctx context.Context, s []string) error {
 	return b.DB.Update(func(tx *bolt.Tx) error {
 		b := tx.Bucket(b.Bucket)
 		for _, key := range s {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
 			err := b.Put([]byte(key), []byte{})
 			if err != nil {
 				return err
@@ -68,3 +98,26 @@ func (b BoltDB) AddMultiple(s []string) error {
 		return nil
 	})
 }
+
+// Remove satisfies the password.DbRemover interface.
+// It removes a single password from the database.
+func (b BoltDB) Remove(s string) error {
+	return b.DB.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(b.Bucket).Delete([]byte(s))
+	})
+}
+
+// RemoveMultiple satisfies the password.BulkRemover interface.
+// It removes a number of passwords from the database in a single transaction.
+func (b BoltDB) RemoveMultiple(s []string) error {
+	return b.DB.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(b.Bucket)
+		for _, key := range s {
+			err := b.Delete([]byte(key))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -36,4 +36,27 @@ func TestBolt(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	err = drivers.TestRemove(bolt)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Test a bolt history store
+func TestHistory(t *testing.T) {
+	db, err := bolt.Open(tempfile(), 0666, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(db.Path())
+	defer db.Close()
+
+	h, err := NewHistory(db, "history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = drivers.TestHistoryStore(h)
+	if err != nil {
+		t.Fatal(err)
+	}
 }
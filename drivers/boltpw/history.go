@@ -0,0 +1,62 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package boltpw
+
+import (
+	"strings"
+
+	"github.com/boltdb/bolt"
+)
+
+// History is a password history store, that keeps the hashes
+// of each user in a single bucket.
+// It satisfies the password.HistoryStore interface.
+type History struct {
+	DB     *bolt.DB
+	Bucket []byte
+}
+
+// NewHistory will return a new history store that read/writes
+// to a single bucket.
+func NewHistory(db *bolt.DB, bucket string) (*History, error) {
+	h := &History{DB: db, Bucket: []byte(bucket)}
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(h.Bucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Hashes are stored newline separated, newest first.
+const historySep = "\n"
+
+// Get satisfies the password.HistoryStore interface.
+func (h History) Get(userID string) ([]string, error) {
+	var res []string
+	err := h.DB.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(h.Bucket).Get([]byte(userID))
+		if len(v) > 0 {
+			res = strings.Split(string(v), historySep)
+		}
+		return nil
+	})
+	return res, err
+}
+
+// Push satisfies the password.HistoryStore interface.
+func (h History) Push(userID, hash string, depth int) error {
+	return h.DB.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(h.Bucket)
+		hashes := []string{hash}
+		if v := b.Get([]byte(userID)); len(v) > 0 {
+			hashes = append(hashes, strings.Split(string(v), historySep)...)
+		}
+		if depth > 0 && len(hashes) > depth {
+			hashes = hashes[:depth]
+		}
+		return b.Put([]byte(userID), []byte(strings.Join(hashes, historySep)))
+	})
+}
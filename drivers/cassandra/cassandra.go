@@ -6,7 +6,11 @@
 // you would like to use.
 package cassandra
 
-import "github.com/gocql/gocql"
+import (
+	"context"
+
+	"github.com/gocql/gocql"
+)
 
 // Cassandra can be used for adding and checking passwords.
 type Cassandra struct {
@@ -33,16 +37,99 @@ func New(session *gocql.Session, table string) *Cassandra {
 
 // Add an entry to the password database
 func (m Cassandra) Add(s string) error {
-	return m.session.Query(`INSERT INTO `+m.table+` (password) VALUES (?)`, s).Exec()
+	return m.AddContext(context.Background(), s)
+}
+
+// AddContext adds an entry to the password database.
+// The context is forwarded to the query.
+func (m Cassandra) AddContext(ctx context.Context, s string) error {
+	return m.session.Query(`INSERT INTO `+m.table+` (password) VALUES (?)`, s).WithContext(ctx).Exec()
 }
 
 // Has will return true if the database has the entry.
 func (m Cassandra) Has(s string) (bool, error) {
+	return m.HasContext(context.Background(), s)
+}
+
+// HasContext will return true if the database has the entry.
+// The context is forwarded to the query.
+func (m Cassandra) HasContext(ctx context.Context, s string) (bool, error) {
 	n := 0
 	if err := m.session.Query(`SELECT COUNT(*) FROM `+m.table+` WHERE password = ?`, s).
-		Consistency(gocql.One).Scan(&n); err != nil {
+		WithContext(ctx).Consistency(gocql.One).Scan(&n); err != nil {
 		return false, err
 	}
 
 	return n != 0, nil
 }
+
+// maxIn is the maximum number of entries sent in a single IN query.
+const maxIn = 100
+
+// HasMultiple will return for each entry if the database has it.
+// Entries are looked up using "IN" queries.
+func (m Cassandra) HasMultiple(s []string) ([]bool, error) {
+	return m.HasMultipleContext(context.Background(), s)
+}
+
+// HasMultipleContext will return for each entry if the database has it.
+// The context is forwarded to the queries.
+func (m Cassandra) HasMultipleContext(ctx context.Context, s []string) ([]bool, error) {
+	res := make([]bool, len(s))
+	for offset := 0; offset < len(s); offset += maxIn {
+		n := len(s) - offset
+		if n > maxIn {
+			n = maxIn
+		}
+		index := make(map[string][]int, n)
+		for i, pass := range s[offset : offset+n] {
+			index[pass] = append(index[pass], offset+i)
+		}
+		iter := m.session.Query(`SELECT password FROM `+m.table+` WHERE password IN ?`, s[offset:offset+n]).
+			WithContext(ctx).Consistency(gocql.One).Iter()
+		var found string
+		for iter.Scan(&found) {
+			for _, i := range index[found] {
+				res[i] = true
+			}
+		}
+		if err := iter.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// Remove an entry from the password database.
+func (m Cassandra) Remove(s string) error {
+	return m.RemoveContext(context.Background(), s)
+}
+
+// RemoveContext removes an entry from the password database.
+// The context is forwarded to the query.
+func (m Cassandra) RemoveContext(ctx context.Context, s string) error {
+	return m.session.Query(`DELETE FROM `+m.table+` WHERE password = ?`, s).WithContext(ctx).Exec()
+}
+
+// RemoveMultiple removes multiple entries from the password database,
+// using "IN" queries.
+func (m Cassandra) RemoveMultiple(s []string) error {
+	return m.RemoveMultipleContext(context.Background(), s)
+}
+
+// RemoveMultipleContext removes multiple entries from the password database.
+// The context is forwarded to the queries.
+func (m Cassandra) RemoveMultipleContext(ctx context.Context, s []string) error {
+	for len(s) > 0 {
+		n := len(s)
+		if n > maxIn {
+			n = maxIn
+		}
+		err := m.session.Query(`DELETE FROM `+m.table+` WHERE password IN ?`, s[:n]).WithContext(ctx).Exec()
+		if err != nil {
+			return err
+		}
+		s = s[n:]
+	}
+	return nil
+}
@@ -43,4 +43,9 @@ func TestCassandra(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+
+	err = drivers.TestRemove(db)
+	if err != nil {
+		t.Fatal(err)
+	}
 }
@@ -9,6 +9,9 @@
 package mgopw
 
 import (
+	"context"
+	"time"
+
 	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
@@ -42,6 +45,22 @@ func (m Mongo) Add(s string) error {
 	return err
 }
 
+// AddContext adds an entry to the password database.
+// See HasContext for how the context is used.
+func (m Mongo) AddContext(ctx context.Context, s string) error {
+	session, err := m.sessionContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+	s = truncate(s)
+	_, err = session.DB(m.db).C(m.collection).UpsertId(s, bson.M{"_id": s})
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
 // Has will return true if the database has the entry.
 func (m Mongo) Has(s string) (bool, error) {
 	s = truncate(s)
@@ -52,6 +71,152 @@ func (m Mongo) Has(s string) (bool, error) {
 	return n > 0, nil
 }
 
+// HasContext will return true if the database has the entry.
+//
+// The mgo driver does not support contexts, so the context is
+// checked before the query is sent, and if the context has a deadline
+// it is used as socket timeout for the query.
+func (m Mongo) HasContext(ctx context.Context, s string) (bool, error) {
+	session, err := m.sessionContext(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer session.Close()
+	s = truncate(s)
+	n, err := session.DB(m.db).C(m.collection).FindId(s).Count()
+	if err != nil {
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// HasMultiple will return for each entry if the database has it.
+// All entries are looked up using a single "$in" query.
+func (m Mongo) HasMultiple(s []string) ([]bool, error) {
+	return m.hasMultiple(m.session, s)
+}
+
+// HasMultipleContext will return for each entry if the database has it.
+// See HasContext for how the context is used.
+func (m Mongo) HasMultipleContext(ctx context.Context, s []string) ([]bool, error) {
+	session, err := m.sessionContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+	res, err := m.hasMultiple(session, s)
+	if err != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return res, err
+}
+
+func (m Mongo) hasMultiple(session *mgo.Session, s []string) ([]bool, error) {
+	// Entries are matched on the truncated value.
+	index := make(map[string][]int, len(s))
+	keys := make([]string, 0, len(s))
+	for i, pass := range s {
+		t := truncate(pass)
+		if _, ok := index[t]; !ok {
+			keys = append(keys, t)
+		}
+		index[t] = append(index[t], i)
+	}
+	res := make([]bool, len(s))
+	iter := session.DB(m.db).C(m.collection).Find(bson.M{"_id": bson.M{"$in": keys}}).Select(bson.M{"_id": 1}).Iter()
+	var doc struct {
+		ID string `bson:"_id"`
+	}
+	for iter.Next(&doc) {
+		for _, i := range index[doc.ID] {
+			res[i] = true
+		}
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Remove an entry from the password database.
+func (m Mongo) Remove(s string) error {
+	err := m.session.DB(m.db).C(m.collection).RemoveId(truncate(s))
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+// RemoveContext removes an entry from the password database.
+// See HasContext for how the context is used.
+func (m Mongo) RemoveContext(ctx context.Context, s string) error {
+	session, err := m.sessionContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+	err = session.DB(m.db).C(m.collection).RemoveId(truncate(s))
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// RemoveMultiple removes multiple entries from the password database,
+// using a single "$in" query.
+func (m Mongo) RemoveMultiple(s []string) error {
+	return m.removeMultiple(m.session, s)
+}
+
+// RemoveMultipleContext removes multiple entries from the password database.
+// See HasContext for how the context is used.
+func (m Mongo) RemoveMultipleContext(ctx context.Context, s []string) error {
+	session, err := m.sessionContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+	err = m.removeMultiple(session, s)
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+func (m Mongo) removeMultiple(session *mgo.Session, s []string) error {
+	keys := make([]string, len(s))
+	for i, pass := range s {
+		keys[i] = truncate(pass)
+	}
+	_, err := session.DB(m.db).C(m.collection).RemoveAll(bson.M{"_id": bson.M{"$in": keys}})
+	return err
+}
+
+// sessionContext returns a copy of the session, with the socket timeout
+// set to the deadline of the context, if any.
+// The returned session must be closed after use.
+func (m Mongo) sessionContext(ctx context.Context) (*mgo.Session, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	session := m.session.Copy()
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout := deadline.Sub(time.Now())
+		if timeout <= 0 {
+			session.Close()
+			return nil, context.DeadlineExceeded
+		}
+		session.SetSocketTimeout(timeout)
+	}
+	return session, nil
+}
+
 // Cut runes off the end until the
 // string is below 512 bytes.
 func truncate(s string) string {
@@ -40,6 +40,11 @@ func TestMongo(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	err = drivers.TestRemove(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	err = coll.DropCollection()
 	if err != nil {
 		t.Log("Drop returned", err, "(ignoring)")
@@ -35,6 +35,10 @@ func TestMySQL(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	err = drivers.TestRemove(d)
+	if err != nil {
+		t.Fatal(err)
+	}
 	_, err = db.Exec(drop)
 	if err != nil {
 		t.Log("DROP returned:", err)
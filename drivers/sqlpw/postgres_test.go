@@ -62,6 +62,10 @@ func TestPostgres(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	err = drivers.TestRemove(d)
+	if err != nil {
+		t.Fatal(err)
+	}
 	_, err = db.Exec(drop)
 	if err != nil {
 		t.Log("DROP returned:", err)
@@ -17,7 +17,11 @@
 package sqlpw
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
 )
 
 // Sql can be used for adding and checking passwords.
@@ -26,12 +30,19 @@ import (
 type Sql struct {
 	TxBulk bool // Do bulk inserts with a transaction.
 	db     *sql.DB
-	query  string // Query string, used to get a count of hits
-	insert string // Insert string,used to insert an item
+	query  string             // Query string, used to get a count of hits
+	insert string             // Insert string,used to insert an item
+	remove string             // Delete string, used to remove an item
+	multi  func(n int) string // Returns a query returning the entries matching n parameters
 	qStmt  *sql.Stmt
 	iStmt  *sql.Stmt
+	dStmt  *sql.Stmt
 }
 
+// ErrNoRemove is returned by Remove and RemoveMultiple if
+// no remove statement has been set. See SetRemove.
+var ErrNoRemove = errors.New("sqlpw: no remove statement set")
+
 // New returns a new database.
 //
 // You must give an query, that returns the number of
@@ -63,6 +74,10 @@ func NewMysql(db *sql.DB, schema, column string) *Sql {
 		db:     db,
 		query:  "SELECT COUNT(*) FROM `" + schema + "` WHERE `" + column + "`=?;",
 		insert: "INSERT IGNORE INTO `" + schema + "` (`" + column + "`) VALUE (?);",
+		remove: "DELETE FROM `" + schema + "` WHERE `" + column + "`=?;",
+		multi: func(n int) string {
+			return "SELECT `" + column + "` FROM `" + schema + "` WHERE `" + column + "` IN (" + placeholders(n, false) + ");"
+		},
 	}
 	return &s
 }
@@ -77,72 +92,254 @@ func NewPostgresql(db *sql.DB, table, column string) *Sql {
 		db:     db,
 		insert: `INSERT INTO ` + table + ` (` + column + `) VALUES ($1)`,
 		query:  `SELECT COUNT(*) FROM  ` + table + ` WHERE ` + column + `=$1`,
+		remove: `DELETE FROM ` + table + ` WHERE ` + column + `=$1`,
+		multi: func(n int) string {
+			return `SELECT ` + column + ` FROM ` + table + ` WHERE ` + column + ` IN (` + placeholders(n, true) + `)`
+		},
 	}
 	return &s
 }
 
+// SetRemove sets the statement used to remove a password.
+// It must remove the password given, and not return an error
+// if the password is not in the database.
+// Databases created by NewMysql and NewPostgresql already have it set.
+func (m *Sql) SetRemove(remove string) {
+	m.remove = remove
+	m.dStmt = nil
+}
+
 // Add an entry to the password database
 func (m *Sql) Add(s string) error {
+	return m.AddContext(context.Background(), s)
+}
+
+// AddContext adds an entry to the password database.
+// The context is forwarded to the database driver.
+func (m *Sql) AddContext(ctx context.Context, s string) error {
 	var err error
 	if m.iStmt == nil {
-		m.iStmt, err = m.db.Prepare(m.insert)
+		m.iStmt, err = m.db.PrepareContext(ctx, m.insert)
 		if err != nil {
 			return err
 		}
 	}
-	_, err = m.iStmt.Exec(truncate(s))
+	_, err = m.iStmt.ExecContext(ctx, truncate(s))
 	return err
 }
 
 // Add multiple entries to the password database
 func (m *Sql) AddMultiple(s []string) error {
+	return m.AddMultipleContext(context.Background(), s)
+}
+
+// AddMultipleContext adds multiple entries to the password database.
+// If the context is cancelled, the transaction is rolled back.
+func (m *Sql) AddMultipleContext(ctx context.Context, s []string) error {
 	var err error
 	if !m.TxBulk {
 		for _, pass := range s {
-			err = m.Add(pass)
+			err = m.AddContext(ctx, pass)
 			if err != nil {
 				return err
 			}
 		}
 		return nil
 	}
-	tx, err := m.db.Begin()
+	tx, err := m.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 
-	stmt, err := tx.Prepare(m.insert)
+	stmt, err := tx.PrepareContext(ctx, m.insert)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for _, pass := range s {
+		_, err = stmt.ExecContext(ctx, truncate(pass))
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Remove an entry from the password database.
+func (m *Sql) Remove(s string) error {
+	return m.RemoveContext(context.Background(), s)
+}
+
+// RemoveContext removes an entry from the password database.
+// The context is forwarded to the database driver.
+func (m *Sql) RemoveContext(ctx context.Context, s string) error {
+	if m.remove == "" {
+		return ErrNoRemove
+	}
+	var err error
+	if m.dStmt == nil {
+		m.dStmt, err = m.db.PrepareContext(ctx, m.remove)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = m.dStmt.ExecContext(ctx, truncate(s))
+	return err
+}
+
+// RemoveMultiple removes multiple entries from the password database.
+// If TxBulk is set, the entries are removed in a single transaction.
+func (m *Sql) RemoveMultiple(s []string) error {
+	return m.RemoveMultipleContext(context.Background(), s)
+}
+
+// RemoveMultipleContext removes multiple entries from the password database.
+// If the context is cancelled, the transaction is rolled back.
+func (m *Sql) RemoveMultipleContext(ctx context.Context, s []string) error {
+	if m.remove == "" {
+		return ErrNoRemove
+	}
+	if !m.TxBulk {
+		for _, pass := range s {
+			err := m.RemoveContext(ctx, pass)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	tx, err := m.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	stmt, err := tx.PrepareContext(ctx, m.remove)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
 	defer stmt.Close()
 
 	for _, pass := range s {
-		_, err = stmt.Exec(truncate(pass))
+		_, err = stmt.ExecContext(ctx, truncate(pass))
 		if err != nil {
+			tx.Rollback()
 			return err
 		}
 	}
 	return tx.Commit()
 }
 
+// ConcurrentSafe satisfies the password.ConcurrentBulkWriter interface.
+// Batches can be written concurrently when TxBulk is enabled,
+// since each batch is then written in its own transaction.
+func (m *Sql) ConcurrentSafe() bool {
+	return m.TxBulk
+}
+
 // Has will return true if the database has the entry.
 func (m *Sql) Has(s string) (bool, error) {
+	return m.HasContext(context.Background(), s)
+}
+
+// HasContext will return true if the database has the entry.
+// The context is forwarded to the database driver.
+func (m *Sql) HasContext(ctx context.Context, s string) (bool, error) {
 	var err error
 	if m.qStmt == nil {
-		m.qStmt, err = m.db.Prepare(m.query)
+		m.qStmt, err = m.db.PrepareContext(ctx, m.query)
 		if err != nil {
 			return false, err
 		}
 	}
 	var num int
-	err = m.qStmt.QueryRow(truncate(s)).Scan(&num)
+	err = m.qStmt.QueryRowContext(ctx, truncate(s)).Scan(&num)
 	if err != nil {
 		return false, err
 	}
 	return num > 0, nil
 }
 
+// maxIn is the maximum number of parameters sent in a single IN query.
+const maxIn = 500
+
+// HasMultiple will return for each entry if the database has it.
+//
+// For MySQL and PostgreSQL, entries are looked up with "IN" queries.
+// If the database was created with New, entries are looked up one by one.
+func (m *Sql) HasMultiple(s []string) ([]bool, error) {
+	return m.HasMultipleContext(context.Background(), s)
+}
+
+// HasMultipleContext will return for each entry if the database has it.
+// The context is forwarded to the database driver.
+func (m *Sql) HasMultipleContext(ctx context.Context, s []string) ([]bool, error) {
+	res := make([]bool, len(s))
+	if m.multi == nil {
+		var err error
+		for i, pass := range s {
+			res[i], err = m.HasContext(ctx, pass)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	}
+	for offset := 0; offset < len(s); offset += maxIn {
+		n := len(s) - offset
+		if n > maxIn {
+			n = maxIn
+		}
+		// Entries are matched on the truncated value.
+		index := make(map[string][]int, n)
+		args := make([]interface{}, n)
+		for i, pass := range s[offset : offset+n] {
+			t := truncate(pass)
+			index[t] = append(index[t], offset+i)
+			args[i] = t
+		}
+		rows, err := m.db.QueryContext(ctx, m.multi(n), args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var found string
+			if err := rows.Scan(&found); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			for _, i := range index[found] {
+				res[i] = true
+			}
+		}
+		err = rows.Close()
+		if err == nil {
+			err = rows.Err()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// placeholders returns n comma separated query placeholders.
+// If numbered is true, PostgreSQL style "$1" placeholders are used,
+// otherwise "?" is used.
+func placeholders(n int, numbered bool) string {
+	p := make([]string, n)
+	for i := range p {
+		if numbered {
+			p[i] = "$" + strconv.Itoa(i+1)
+		} else {
+			p[i] = "?"
+		}
+	}
+	return strings.Join(p, ",")
+}
+
 func truncate(s string) string {
 	r := []rune(s)
 	if len(r) <= 64 {
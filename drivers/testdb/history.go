@@ -0,0 +1,67 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package testdb
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The password history is stored in the same map as the passwords.
+// Each hash is stored as a key with historyPrefix, the hex encoded
+// user ID, a sequence number and the hash, separated by zero bytes.
+// The sequence number increases, so the newest hash sorts last.
+const historyPrefix = "\x00history\x00"
+
+// userPrefix returns the prefix of the history keys of the user.
+func userPrefix(userID string) string {
+	return historyPrefix + hex.EncodeToString([]byte(userID)) + "\x00"
+}
+
+// historyKeys returns the history keys of the user, oldest first,
+// and the sequence number of the next hash.
+func (m MemDB) historyKeys(userID string) ([]string, uint64) {
+	prefix := userPrefix(userID)
+	var keys []string
+	for k := range m {
+		if strings.HasPrefix(k, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var next uint64
+	if len(keys) > 0 {
+		last := strings.TrimPrefix(keys[len(keys)-1], prefix)
+		fmt.Sscanf(last, "%016x", &next)
+		next++
+	}
+	return keys, next
+}
+
+// Get returns the hashes stored for the user, newest first.
+// It satisfies the password.HistoryStore interface.
+func (m MemDB) Get(userID string) ([]string, error) {
+	keys, _ := m.historyKeys(userID)
+	res := make([]string, 0, len(keys))
+	for i := len(keys) - 1; i >= 0; i-- {
+		res = append(res, keys[i][strings.LastIndexByte(keys[i], 0)+1:])
+	}
+	return res, nil
+}
+
+// Push adds a hash for the user, keeping at most depth hashes.
+// It satisfies the password.HistoryStore interface.
+func (m *MemDB) Push(userID, hash string, depth int) error {
+	db := *m
+	keys, next := db.historyKeys(userID)
+	db[fmt.Sprintf("%s%016x\x00%s", userPrefix(userID), next, hash)] = struct{}{}
+	// keys does not contain the new hash.
+	if n := len(keys) + 1 - depth; depth > 0 && n > 0 {
+		for _, k := range keys[:n] {
+			delete(db, k)
+		}
+	}
+	return nil
+}
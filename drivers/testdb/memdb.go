@@ -14,6 +14,9 @@ package testdb
 
 // This is the simplest possible database that must be supported.
 // If you can mimmic this with your database you are good to go!
+//
+// MemDB can also be used as a password.HistoryStore.
+// The history is stored in the same map as the passwords.
 type MemDB map[string]struct{}
 
 // NewMemDB creates a new MemDB instance
@@ -31,6 +34,13 @@ func (m *MemDB) Add(s string) error {
 	return nil
 }
 
+// Remove a password from the MemDB.
+// It must silently ignore passwords not in the database.
+func (m *MemDB) Remove(s string) error {
+	delete(*m, s)
+	return nil
+}
+
 // Has will check if the database has a specific password.
 // If any error is returned, it will be forwarded to your
 // "Check()" call.
@@ -71,3 +81,28 @@ func (m MemDBBulk) Has(s string) (bool, error) {
 	_, ok := m[s]
 	return ok, nil
 }
+
+// HasMultiple returns for each string if the map has it.
+func (m MemDBBulk) HasMultiple(s []string) ([]bool, error) {
+	res := make([]bool, len(s))
+	for i, p := range s {
+		_, res[i] = m[p]
+	}
+	return res, nil
+}
+
+// Remove a single entry
+func (m *MemDBBulk) Remove(s string) error {
+	delete(*m, s)
+	return nil
+}
+
+// RemoveMultiple is the function that will be called
+// with several items to remove at once.
+func (m *MemDBBulk) RemoveMultiple(s []string) error {
+	db := *m
+	for _, p := range s {
+		delete(db, p)
+	}
+	return nil
+}
@@ -10,7 +10,12 @@ import (
 
 // Test a MemDB database
 func TestMemDB(t *testing.T) {
-	err := drivers.TestDriver(NewMemDB())
+	db := NewMemDB()
+	err := drivers.TestDriver(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = drivers.TestRemove(db)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -18,8 +23,33 @@ func TestMemDB(t *testing.T) {
 
 // Test a MemDBBulk database
 func TestMemDBBulk(t *testing.T) {
-	err := drivers.TestDriver(NewMemDBBulk())
+	db := NewMemDBBulk()
+	err := drivers.TestDriver(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = drivers.TestRemove(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Test the password history of a MemDB
+func TestMemDBHistory(t *testing.T) {
+	db := NewMemDB()
+	err := db.Add("password1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = drivers.TestHistoryStore(db)
 	if err != nil {
 		t.Fatal(err)
 	}
+	// The history does not affect the passwords.
+	if has, _ := db.Has("password1"); !has {
+		t.Fatal("password1 was removed")
+	}
+	if has, _ := db.Has("hash4"); has {
+		t.Fatal("history hash found as password")
+	}
 }
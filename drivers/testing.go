@@ -17,7 +17,10 @@ import (
 	"github.com/klauspost/password/tokenizer"
 
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"strings"
 )
 
 type TestDB interface {
@@ -84,10 +87,10 @@ func TestData(db password.DB) error {
 			continue
 		}
 		err := password.Check(p, db, nil)
-		if err != password.ErrPasswordInDB {
+		if !errors.Is(err, password.ErrPasswordInDB) {
 			return fmt.Errorf("%s not found in database: %v", p, err)
 		}
-		if err != password.ErrPasswordInDB && err != nil {
+		if !errors.Is(err, password.ErrPasswordInDB) && err != nil {
 			return fmt.Errorf("check %s returned unexpected error: %v", p, err)
 		}
 	}
@@ -96,7 +99,7 @@ func TestData(db password.DB) error {
 			continue
 		}
 		err := password.Check(p, db, nil)
-		if err == password.ErrPasswordInDB {
+		if errors.Is(err, password.ErrPasswordInDB) {
 			return fmt.Errorf("%s should NOT be not found in database: %v", p, err)
 		} else if err != nil {
 			return err
@@ -117,5 +120,183 @@ func TestData(db password.DB) error {
 	if err != nil {
 		return err
 	}
+
+	// Test CheckMany, which will use HasMultiple if supported.
+	var passwords []string
+	var inSet []bool
+	for p := range testdata.TestSet {
+		passwords = append(passwords, p)
+		inSet = append(inSet, true)
+	}
+	for p := range testdata.NotInSet {
+		passwords = append(passwords, p)
+		inSet = append(inSet, false)
+	}
+	errs := password.CheckMany(passwords, db, nil)
+	for i, p := range passwords {
+		if password.SanitizeOK(p, nil) != nil {
+			continue
+		}
+		if inSet[i] && !errors.Is(errs[i], password.ErrPasswordInDB) {
+			return fmt.Errorf("%s not found in database by CheckMany: %v", p, errs[i])
+		}
+		if !inSet[i] && errs[i] != nil {
+			return fmt.Errorf("CheckMany %s returned unexpected error: %v", p, errs[i])
+		}
+	}
+
+	// Test context lookups, if supported.
+	if dbc, ok := db.(password.DBContext); ok {
+		has, err = dbc.HasContext(context.Background(), single_val)
+		if err != nil {
+			return err
+		}
+		if !has {
+			return fmt.Errorf("%s not found in database. (HasContext)", single_val)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err = dbc.HasContext(ctx, single_val)
+		if err == nil {
+			return fmt.Errorf("HasContext with cancelled context did not return an error")
+		}
+	}
+	return nil
+}
+
+// TestRemove will test that passwords can be removed from
+// a database that has been populated with TestImport.
+// It will test "Remove" and "RemoveMultiple" (if available).
+// After the test, the removed passwords are no longer in the database.
+// If any error is returned the test failed.
+func TestRemove(db interface {
+	password.DB
+	password.DbRemover
+}) error {
+	// Split the test set in two, so entries that
+	// are stored as the same value end up together.
+	var remove, keep []string
+	removed := make(map[string]bool)
+	for p := range testdata.TestSet {
+		v, err := password.Sanitize(p, nil)
+		if err != nil {
+			continue
+		}
+		key := strings.ToLower(v)
+		rm, ok := removed[key]
+		if !ok {
+			rm = len(remove) <= len(keep)
+			removed[key] = rm
+		}
+		if rm {
+			remove = append(remove, p)
+		} else {
+			keep = append(keep, p)
+		}
+	}
+	// Also remove something that isn't there.
+	in := tokenizer.NewLine(strings.NewReader(strings.Join(remove, "\n") + "\nnot-in-the-database"))
+	err := password.Remove(in, db, nil)
+	if err != nil {
+		return err
+	}
+	for _, p := range remove {
+		err := password.Check(p, db, nil)
+		if errors.Is(err, password.ErrPasswordInDB) {
+			return fmt.Errorf("%s was not removed from database", p)
+		} else if err != nil {
+			return err
+		}
+	}
+	for _, p := range keep {
+		err := password.Check(p, db, nil)
+		if !errors.Is(err, password.ErrPasswordInDB) {
+			return fmt.Errorf("%s not found in database after removal: %v", p, err)
+		}
+	}
+
+	// Test Remove once separately
+	err = db.Remove(single_val)
+	if err != nil {
+		return err
+	}
+	has, err := db.Has(single_val)
+	if err != nil {
+		return err
+	}
+	if has {
+		return fmt.Errorf("%s found in database after Remove", single_val)
+	}
+	// .. and test that it is ok to remove it again.
+	err = db.Remove(single_val)
+	if err != nil {
+		return err
+	}
+
+	// Test context removal, if supported.
+	if rc, ok := db.(password.DbRemoverContext); ok {
+		err = rc.RemoveContext(context.Background(), single_val)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if rc.RemoveContext(ctx, single_val) == nil {
+			return fmt.Errorf("RemoveContext with cancelled context did not return an error")
+		}
+	}
+	if rc, ok := db.(password.BulkRemoverContext); ok {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if rc.RemoveMultipleContext(ctx, []string{single_val}) == nil {
+			return fmt.Errorf("RemoveMultipleContext with cancelled context did not return an error")
+		}
+	}
 	return nil
 }
+
+// TestHistoryStore will test a password history store.
+// The store should be empty.
+// If any error is returned the test failed.
+func TestHistoryStore(store password.HistoryStore) error {
+	h, err := store.Get("user1")
+	if err != nil {
+		return err
+	}
+	if len(h) != 0 {
+		return fmt.Errorf("expected empty history, got %v", h)
+	}
+	for i := 0; i < 5; i++ {
+		err = store.Push("user1", fmt.Sprintf("hash%d", i), 3)
+		if err != nil {
+			return err
+		}
+	}
+	err = store.Push("user2", "other", 3)
+	if err != nil {
+		return err
+	}
+	h, err = store.Get("user1")
+	if err != nil {
+		return err
+	}
+	if fmt.Sprint(h) != "[hash4 hash3 hash2]" {
+		return fmt.Errorf("expected newest 3 hashes, got %v", h)
+	}
+
+	// Check the history using password.History.
+	hist := &password.History{Store: store, Depth: 3, Hasher: password.Argon2id{Memory: 64, Time: 1}}
+	err = hist.Record("user3", "MyFirstPassword")
+	if err != nil {
+		return err
+	}
+	err = hist.CheckHistory("user3", "  MyFirstPassword  ")
+	if !errors.Is(err, password.ErrPasswordReused) {
+		return fmt.Errorf("expected ErrPasswordReused, got %v", err)
+	}
+	err = hist.CheckHistory("user3", "MySecondPassword")
+	if err != nil {
+		return err
+	}
+	return hist.CheckHistory("user4", "MyFirstPassword")
+}
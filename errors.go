@@ -0,0 +1,71 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import "errors"
+
+// Reason is a machine readable code describing why a password was rejected.
+//
+// Custom sanitizers can define their own reasons and return them
+// in a CheckError. Use a prefix for your own codes to avoid
+// collisions with codes added to this package later.
+type Reason string
+
+// Reasons returned by this package.
+const (
+	ReasonTooShort Reason = "too_short"    // Password is too short
+	ReasonTooLong  Reason = "too_long"     // Password is too long
+	ReasonInvalid  Reason = "invalid_utf8" // Password is not valid utf8
+	ReasonInDB     Reason = "in_database"  // Password found in database
+
+	ReasonForbiddenRune  Reason = "forbidden_rune" // Password contains a forbidden character
+	ReasonDenied         Reason = "denied"         // Password matches a denied pattern
+	ReasonTooFewDistinct Reason = "few_distinct"   // Password has too few different characters
+	ReasonUserContext    Reason = "user_context"   // Password is based on user information
+	ReasonPattern        Reason = "pattern"        // Password consists of simple patterns
+	ReasonKeyboardWalk   Reason = "keyboard_walk"  // Password consists of adjacent keys
+	ReasonInvisible      Reason = "invisible"      // Password contains invisible or control characters
+	ReasonReused         Reason = "reused"         // Password has been used before
+	ReasonTooSimilar     Reason = "too_similar"    // Password is too similar to the previous password
+)
+
+// CheckError is returned by sanitizers and Check when a password is rejected.
+//
+// The error wraps the sentinel error it represents, so
+// errors.Is(err, ErrPasswordInDB) can be used to test for it.
+//
+// Note that Normalized contains the (normalized) password, so
+// be careful not to log it.
+type CheckError struct {
+	Reason     Reason // Machine readable reason.
+	Min        int    // Minimum length in runes, if relevant.
+	Max        int    // Maximum length in runes, if relevant.
+	Actual     int    // Actual length in runes, if relevant.
+	Normalized string // The normalized password that was looked up, if any.
+	Variant    string // The variant of the password found in the database, if not the password itself.
+	Detail     string // Additional information, for instance a forbidden character.
+	Err        error  // Underlying error, for instance ErrPasswordInDB.
+}
+
+// Error returns the message of the underlying error.
+func (e *CheckError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return "password rejected: " + string(e.Reason)
+}
+
+// Unwrap returns the underlying error.
+func (e *CheckError) Unwrap() error {
+	return e.Err
+}
+
+// ReasonOf returns the reason of a CheckError in the chain of err.
+// If err does not contain a CheckError, an empty string is returned.
+func ReasonOf(err error) Reason {
+	var ce *CheckError
+	if errors.As(err, &ce) {
+		return ce.Reason
+	}
+	return ""
+}
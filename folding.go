@@ -0,0 +1,87 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"strings"
+
+	"golang.org/x/text/cases"
+)
+
+// A Folding maps passwords to a single case, so passwords
+// that only differ in case are stored and looked up as the same value.
+//
+// The same Folding must be used when importing a dictionary
+// and when checking passwords against it.
+// ImportStats.Folding contains the name of the Folding used for an import,
+// and FoldingByName can be used to get the Folding from the name.
+type Folding interface {
+	// Name returns a unique name of the folding.
+	Name() string
+
+	// Fold returns s with case folded.
+	Fold(s string) string
+}
+
+// Built-in foldings.
+var (
+	// FoldLower uses strings.ToLower. This is the default.
+	FoldLower Folding = foldLower{}
+
+	// FoldUnicode uses Unicode full case folding,
+	// so "ß" and "ss" or "ς" and "σ" are treated the same.
+	// The Turkish dotted "İ" is folded to "i", by removing the dot
+	// above that full case folding leaves after the "i".
+	// Folding is not language specific, so the Turkish dotless "ı"
+	// is not folded to "i". Use Confusables to also match these.
+	FoldUnicode Folding = foldUnicode{}
+)
+
+// FoldingByName returns the built-in Folding with the specified name.
+// If no folding has the name, false is returned.
+func FoldingByName(name string) (Folding, bool) {
+	for _, f := range []Folding{FoldLower, FoldUnicode} {
+		if f.Name() == name {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+type foldLower struct{}
+
+func (foldLower) Name() string {
+	return "lower"
+}
+
+func (foldLower) Fold(s string) string {
+	return strings.ToLower(s)
+}
+
+type foldUnicode struct{}
+
+func (foldUnicode) Name() string {
+	return "unicode"
+}
+
+func (foldUnicode) Fold(s string) string {
+	// A Caser is not safe for concurrent use, so create one for each call.
+	s = cases.Fold().String(s)
+	if !strings.Contains(s, "i\u0307") {
+		return s
+	}
+	return strings.ReplaceAll(s, "i\u0307", "i")
+}
+
+// dbKey returns the key used for the sanitized password s in the database.
+// If fold is nil, FoldLower is used.
+// If confusables is true, homoglyphs in s are folded.
+func dbKey(s string, fold Folding, confusables bool) string {
+	if confusables {
+		s = FoldHomoglyphs(s)
+	}
+	if fold == nil {
+		fold = FoldLower
+	}
+	return fold.Fold(s)
+}
@@ -0,0 +1,114 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/klauspost/password/drivers/testdb"
+)
+
+func TestFolding(t *testing.T) {
+	tests := []struct {
+		in    string
+		lower string
+		fold  string
+	}{
+		{"Straße", "straße", "strasse"},
+		{"STRASSE", "strasse", "strasse"},
+		{"ΣΊΣΥΦΟΣ", "σίσυφοσ", "σίσυφοσ"},
+		{"σίσυφος", "σίσυφος", "σίσυφοσ"},
+		{"Password", "password", "password"},
+		{"İSTANBUL", "istanbul", "istanbul"},
+		{"I\u0307stanbul", "i\u0307stanbul", "istanbul"},
+	}
+	for _, test := range tests {
+		if got := FoldLower.Fold(test.in); got != test.lower {
+			t.Errorf("FoldLower(%q): expected %q, got %q", test.in, test.lower, got)
+		}
+		if got := FoldUnicode.Fold(test.in); got != test.fold {
+			t.Errorf("FoldUnicode(%q): expected %q, got %q", test.in, test.fold, got)
+		}
+	}
+	for _, f := range []Folding{FoldLower, FoldUnicode} {
+		got, ok := FoldingByName(f.Name())
+		if !ok || got != f {
+			t.Errorf("FoldingByName(%q): got %v, %v", f.Name(), got, ok)
+		}
+	}
+	if _, ok := FoldingByName("unknown"); ok {
+		t.Error("expected unknown folding to not be found")
+	}
+}
+
+func TestImportCheckFolding(t *testing.T) {
+	db := testdb.NewMemDB()
+	in := sliceTokenizer{"straßenbahn", "password"}
+	stats, err := ImportWithOptions(&in, db, ImportOptions{Folding: FoldUnicode})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Folding != "unicode" {
+		t.Fatal("expected unicode folding, got", stats.Folding)
+	}
+	fold, ok := FoldingByName(stats.Folding)
+	if !ok {
+		t.Fatal("folding not found")
+	}
+	for _, pw := range []string{"STRASSENBAHN", "Straßenbahn", "strassenbahn"} {
+		err := CheckWithOptions(pw, db, CheckOptions{Folding: fold})
+		if !errors.Is(err, ErrPasswordInDB) {
+			t.Errorf("%q: expected ErrPasswordInDB, got %v", pw, err)
+		}
+	}
+	// The default folding does not match.
+	if err := CheckWithOptions("Straßenbahn", db, CheckOptions{}); err != nil {
+		t.Fatal("unexpected error", err)
+	}
+
+	stats, err = ImportWithOptions(&sliceTokenizer{}, db, ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Folding != "lower" {
+		t.Fatal("expected lower folding, got", stats.Folding)
+	}
+}
+
+func TestFoldingOptions(t *testing.T) {
+	db := testdb.NewMemDB()
+	in := sliceTokenizer{"istanbul2024", "straßenbahn"}
+	_, err := ImportWithOptions(&in, db, ImportOptions{Folding: FoldUnicode})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckWithOptions("İSTANBUL2024", db, CheckOptions{Folding: FoldUnicode}); !errors.Is(err, ErrPasswordInDB) {
+		t.Fatal("expected ErrPasswordInDB, got", err)
+	}
+	errs := CheckManyWithOptions([]string{"İstanbul2024", "STRASSENBAHN", "notindb12"}, db, CheckOptions{Folding: FoldUnicode})
+	if !errors.Is(errs[0], ErrPasswordInDB) || !errors.Is(errs[1], ErrPasswordInDB) || errs[2] != nil {
+		t.Fatal("unexpected errors", errs)
+	}
+
+	allow := NewAllowSetWithOptions(ImportOptions{Folding: FoldUnicode}, "STRASSENBAHN")
+	opts := CheckOptions{Folding: FoldUnicode}
+	if err := CheckWithOptions("Straßenbahn", NewAllowlistDB(db, allow), opts); err != nil {
+		t.Fatal("allowlisted password rejected:", err)
+	}
+
+	p := NISTPolicy(db)
+	p.CheckOptions.Folding = FoldUnicode
+	rep := p.Evaluate("İSTANBUL2024", UserInfo{})
+	if err := rep.Err(); !errors.Is(err, ErrPasswordInDB) {
+		t.Fatal("expected ErrPasswordInDB, got", err)
+	}
+
+	c := ChangePolicy{Folding: FoldUnicode}
+	if err := c.Check("Großstraßenfußball", "GROSSSTRASSENFUSSBALL"); !errors.Is(err, ErrTooSimilar) {
+		t.Fatal("expected ErrTooSimilar, got", err)
+	}
+	if err := CheckChange("Großstraßenfußball", "GROSSSTRASSENFUSSBALL", nil); err != nil {
+		t.Fatal("unexpected error", err)
+	}
+}
@@ -0,0 +1,467 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+)
+
+// A Hasher creates and compares password hashes.
+// Implementations must be safe for concurrent use.
+type Hasher interface {
+	// Hash returns the encoded hash of the password.
+	Hash(password string) (string, error)
+
+	// Compare returns whether the password matches the encoded hash.
+	// ErrUnknownHash is returned if the hash was not created
+	// by this kind of Hasher.
+	Compare(password, hash string) (bool, error)
+
+	// NeedsRehash returns whether the hash was created by
+	// another kind of Hasher or with other parameters.
+	NeedsRehash(hash string) bool
+}
+
+// DefaultHasher is the Hasher used when nil is passed as Hasher.
+var DefaultHasher Hasher = Argon2id{}
+
+// ErrUnknownHash is returned by a Hasher when
+// the format of a hash is not recognized.
+var ErrUnknownHash = errors.New("unknown hash format")
+
+// ErrBcryptTooLong is returned by Bcrypt if the password is longer
+// than 72 bytes, and PreHash is not enabled.
+var ErrBcryptTooLong = errors.New("password is longer than 72 bytes, which bcrypt cannot hash")
+
+// bcryptMaxBytes is the maximum password length used by bcrypt.
+const bcryptMaxBytes = 72
+
+// Limits for the parameters of argon2id and scrypt hashes.
+// Stored hashes outside these are rejected, so a malformed or tampered
+// hash cannot match any password or exhaust memory and CPU,
+// and the hashers will not create them.
+const (
+	minHashSaltLen = 8       // Minimum salt length in bytes.
+	minHashKeyLen  = 16      // Minimum hash length in bytes.
+	maxHashMemory  = 1 << 30 // Maximum memory used to compare, in bytes.
+	maxHashWork    = 4 << 30 // Maximum memory processed to compare, in bytes.
+	maxHashThreads = 16      // Maximum parallelism.
+)
+
+// Hash will sanitize the password and return the encoded hash of it.
+// This should be used when storing a new password, after it has been
+// checked.
+//
+// If nil is passed as Sanitizer, DefaultSanitizer will be used.
+// If nil is passed as Hasher, DefaultHasher will be used.
+func Hash(password string, san Sanitizer, h Hasher) (string, error) {
+	p, err := Sanitize(password, san)
+	if err != nil {
+		return "", err
+	}
+	if h == nil {
+		h = DefaultHasher
+	}
+	return h.Hash(p)
+}
+
+// Verify will sanitize the password and compare it to the encoded hash.
+// The same Sanitizer as when the hash was created must be used.
+//
+// The Hasher is selected based on the format of the hash,
+// so hashes created by Argon2id, Bcrypt and Scrypt can be verified.
+// ErrUnknownHash is returned for other formats.
+// If nil is passed as Sanitizer, DefaultSanitizer will be used.
+func Verify(password, hash string, san Sanitizer) (bool, error) {
+	h := hasherFor(hash)
+	if h == nil {
+		return false, ErrUnknownHash
+	}
+	p, err := Sanitize(password, san)
+	if err != nil {
+		return false, err
+	}
+	return h.Compare(p, hash)
+}
+
+// NeedsRehash returns whether the hash should be replaced by a
+// new hash created with h, because it was created by another kind
+// of Hasher or with other parameters.
+// Call this after a successful Verify, and store a new hash if needed.
+// If nil is passed as Hasher, DefaultHasher will be used.
+func NeedsRehash(hash string, h Hasher) bool {
+	if h == nil {
+		h = DefaultHasher
+	}
+	return h.NeedsRehash(hash)
+}
+
+// hasherFor returns a Hasher that can compare hash,
+// or nil if the format is unknown.
+func hasherFor(hash string) Hasher {
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		return Argon2id{}
+	case strings.HasPrefix(hash, "$scrypt$"):
+		return Scrypt{}
+	case strings.HasPrefix(hash, bcryptHMAC):
+		return Bcrypt{PreHash: true}
+	case isBcrypt(hash):
+		return Bcrypt{}
+	}
+	return nil
+}
+
+// b64 is the base64 encoding used by the PHC string format.
+var b64 = base64.RawStdEncoding
+
+// Argon2id is a Hasher using argon2id.
+// Hashes are encoded in the PHC string format,
+// for instance "$argon2id$v=19$m=19456,t=2,p=1$<salt>$<hash>".
+//
+// The zero value uses the parameters recommended by OWASP.
+type Argon2id struct {
+	Time    uint32 // Number of passes. If 0, 2 is used.
+	Memory  uint32 // Memory in KiB. If 0, 19456 (19 MiB) is used.
+	Threads uint8  // Degree of parallelism. If 0, 1 is used.
+	KeyLen  uint32 // Length of the hash in bytes. If 0, 32 is used.
+	SaltLen int    // Length of the salt in bytes. If 0, 16 is used.
+}
+
+// params returns the parameters with defaults applied.
+func (a Argon2id) params() Argon2id {
+	if a.Time == 0 {
+		a.Time = 2
+	}
+	if a.Memory == 0 {
+		a.Memory = 19 * 1024
+	}
+	if a.Threads == 0 {
+		a.Threads = 1
+	}
+	if a.KeyLen == 0 {
+		a.KeyLen = 32
+	}
+	if a.SaltLen == 0 {
+		a.SaltLen = 16
+	}
+	return a
+}
+
+// valid returns whether the parameters are within the limits.
+// The memory is multiplied by the number of passes, since each
+// pass processes all of it.
+func (a Argon2id) valid() bool {
+	return a.Time > 0 && a.Threads > 0 && a.Threads <= maxHashThreads &&
+		a.Memory >= 8*uint32(a.Threads) && a.Memory <= maxHashMemory/1024 &&
+		uint64(a.Time)*uint64(a.Memory) <= maxHashWork/1024
+}
+
+// Hash returns the PHC encoded argon2id hash of the password.
+func (a Argon2id) Hash(password string) (string, error) {
+	p := a.params()
+	if !p.valid() {
+		return "", fmt.Errorf("argon2id: parameters m=%d,t=%d,p=%d are too large", p.Memory, p.Time, p.Threads)
+	}
+	salt := make([]byte, p.SaltLen)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, p.KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Memory, p.Time, p.Threads, b64.EncodeToString(salt), b64.EncodeToString(key)), nil
+}
+
+// Compare returns whether the password matches the argon2id hash.
+// The parameters stored in the hash are used.
+func (a Argon2id) Compare(password, hash string) (bool, error) {
+	p, salt, key, err := parseArgon2id(hash)
+	if err != nil {
+		return false, err
+	}
+	got := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, p.KeyLen)
+	return subtle.ConstantTimeCompare(got, key) == 1, nil
+}
+
+// NeedsRehash returns whether the hash is not an argon2id hash
+// with the parameters of a.
+func (a Argon2id) NeedsRehash(hash string) bool {
+	p, _, _, err := parseArgon2id(hash)
+	return err != nil || p != a.params()
+}
+
+// parseArgon2id parses a PHC encoded argon2id hash.
+func parseArgon2id(hash string) (p Argon2id, salt, key []byte, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[0] != "" || parts[1] != "argon2id" {
+		return p, nil, nil, ErrUnknownHash
+	}
+	var version int
+	_, err = fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil || version != argon2.Version {
+		return p, nil, nil, fmt.Errorf("argon2id: unsupported version %q", parts[2])
+	}
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads)
+	if err != nil || !p.valid() {
+		return p, nil, nil, fmt.Errorf("argon2id: invalid parameters %q", parts[3])
+	}
+	salt, err = b64.DecodeString(parts[4])
+	if err == nil && len(salt) < minHashSaltLen {
+		err = errors.New("too short")
+	}
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("argon2id: invalid salt: %v", err)
+	}
+	key, err = b64.DecodeString(parts[5])
+	if err == nil && len(key) < minHashKeyLen {
+		err = errors.New("too short")
+	}
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("argon2id: invalid hash: %v", err)
+	}
+	p.SaltLen = len(salt)
+	p.KeyLen = uint32(len(key))
+	return p, salt, key, nil
+}
+
+// Bcrypt is a Hasher using bcrypt.
+// Hashes are encoded in the standard bcrypt format, for instance "$2a$10$...".
+//
+// Note that bcrypt cannot hash passwords longer than 72 bytes, and
+// ErrBcryptTooLong is returned for those, unless PreHash is set.
+// A rune can be up to 4 bytes, so this can be as few as 18 characters.
+type Bcrypt struct {
+	// Cost of the hash. If 0, bcrypt.DefaultCost is used.
+	Cost int
+
+	// PreHash will hash the password with HMAC-SHA256 before it is
+	// sent to bcrypt, so passwords of any length can be used.
+	// The HMAC is keyed with a random salt, so the bcrypt hash cannot
+	// be matched against unsalted SHA-256 hashes from other breaches.
+	// Hashes are encoded as "$bcrypt-hmac-sha256$<salt>$2a$...", and
+	// cannot be verified by other bcrypt implementations.
+	PreHash bool
+}
+
+// bcryptHMAC is prepended to pre-hashed bcrypt hashes,
+// followed by the salt of the HMAC.
+const bcryptHMAC = "$bcrypt-hmac-sha256$"
+
+// key returns the bytes sent to bcrypt.
+// salt is the key of the HMAC, and is only used if PreHash is set.
+func (b Bcrypt) key(password string, salt []byte) []byte {
+	if !b.PreHash {
+		return []byte(password)
+	}
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(password))
+	// Base64 encode the hash, since bcrypt stops at a zero byte.
+	return []byte(base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+// splitBcrypt returns the HMAC salt and the bcrypt hash of a hash
+// created by Bcrypt. The salt is nil if the hash is not pre-hashed.
+func splitBcrypt(hash string) (salt []byte, bhash string, err error) {
+	if !strings.HasPrefix(hash, bcryptHMAC) {
+		if !isBcrypt(hash) {
+			return nil, "", ErrUnknownHash
+		}
+		return nil, hash, nil
+	}
+	hash = hash[len(bcryptHMAC):]
+	i := strings.IndexByte(hash, '$')
+	if i < 0 || !isBcrypt(hash[i:]) {
+		return nil, "", ErrUnknownHash
+	}
+	salt, err = b64.DecodeString(hash[:i])
+	if err == nil && len(salt) < minHashSaltLen {
+		err = errors.New("too short")
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("bcrypt: invalid salt: %v", err)
+	}
+	return salt, hash[i:], nil
+}
+
+func (b Bcrypt) cost() int {
+	if b.Cost == 0 {
+		return bcrypt.DefaultCost
+	}
+	return b.Cost
+}
+
+// Hash returns the bcrypt hash of the password.
+func (b Bcrypt) Hash(password string) (string, error) {
+	if !b.PreHash {
+		if len(password) > bcryptMaxBytes {
+			return "", ErrBcryptTooLong
+		}
+		h, err := bcrypt.GenerateFromPassword(b.key(password, nil), b.cost())
+		return string(h), err
+	}
+	salt := make([]byte, 16)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+	h, err := bcrypt.GenerateFromPassword(b.key(password, salt), b.cost())
+	if err != nil {
+		return "", err
+	}
+	return bcryptHMAC + b64.EncodeToString(salt) + string(h), nil
+}
+
+// Compare returns whether the password matches the bcrypt hash.
+// Hashes created with and without PreHash can be compared.
+func (b Bcrypt) Compare(password, hash string) (bool, error) {
+	salt, hash, err := splitBcrypt(hash)
+	if err != nil {
+		return false, err
+	}
+	b.PreHash = salt != nil
+	err = bcrypt.CompareHashAndPassword([]byte(hash), b.key(password, salt))
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// NeedsRehash returns whether the hash is not a bcrypt hash
+// with the cost and pre-hashing of b.
+func (b Bcrypt) NeedsRehash(hash string) bool {
+	salt, hash, err := splitBcrypt(hash)
+	if err != nil || (salt != nil) != b.PreHash {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != b.cost()
+}
+
+// isBcrypt returns whether hash looks like a bcrypt hash.
+func isBcrypt(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+// Scrypt is a Hasher using scrypt.
+// Hashes are encoded in the PHC string format,
+// for instance "$scrypt$ln=17,r=8,p=1$<salt>$<hash>".
+//
+// The zero value uses the parameters recommended by OWASP,
+// which uses 128 MiB of memory per hash.
+type Scrypt struct {
+	LogN    uint8 // Base 2 logarithm of the CPU/memory cost. If 0, 17 is used.
+	R       int   // Block size. If 0, 8 is used.
+	P       int   // Parallelism. If 0, 1 is used.
+	KeyLen  int   // Length of the hash in bytes. If 0, 32 is used.
+	SaltLen int   // Length of the salt in bytes. If 0, 16 is used.
+}
+
+// params returns the parameters with defaults applied.
+func (s Scrypt) params() Scrypt {
+	if s.LogN == 0 {
+		s.LogN = 17
+	}
+	if s.R == 0 {
+		s.R = 8
+	}
+	if s.P == 0 {
+		s.P = 1
+	}
+	if s.KeyLen == 0 {
+		s.KeyLen = 32
+	}
+	if s.SaltLen == 0 {
+		s.SaltLen = 16
+	}
+	return s
+}
+
+// valid returns whether the parameters are within the limits.
+// scrypt uses 128*r*N bytes of memory, and processes it p times.
+func (s Scrypt) valid() bool {
+	if s.LogN == 0 || s.LogN >= 32 || s.R <= 0 || s.P <= 0 || s.P > maxHashThreads {
+		return false
+	}
+	return s.R <= (maxHashMemory/128)>>s.LogN && s.P*s.R <= (maxHashWork/128)>>s.LogN
+}
+
+// Hash returns the PHC encoded scrypt hash of the password.
+func (s Scrypt) Hash(password string) (string, error) {
+	p := s.params()
+	if !p.valid() {
+		return "", fmt.Errorf("scrypt: parameters ln=%d,r=%d,p=%d are too large", p.LogN, p.R, p.P)
+	}
+	salt := make([]byte, p.SaltLen)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+	key, err := scrypt.Key([]byte(password), salt, 1<<p.LogN, p.R, p.P, p.KeyLen)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("$scrypt$ln=%d,r=%d,p=%d$%s$%s",
+		p.LogN, p.R, p.P, b64.EncodeToString(salt), b64.EncodeToString(key)), nil
+}
+
+// Compare returns whether the password matches the scrypt hash.
+// The parameters stored in the hash are used.
+func (s Scrypt) Compare(password, hash string) (bool, error) {
+	p, salt, key, err := parseScrypt(hash)
+	if err != nil {
+		return false, err
+	}
+	got, err := scrypt.Key([]byte(password), salt, 1<<p.LogN, p.R, p.P, p.KeyLen)
+	if err != nil {
+		return false, err
+	}
+	return subtle.ConstantTimeCompare(got, key) == 1, nil
+}
+
+// NeedsRehash returns whether the hash is not a scrypt hash
+// with the parameters of s.
+func (s Scrypt) NeedsRehash(hash string) bool {
+	p, _, _, err := parseScrypt(hash)
+	return err != nil || p != s.params()
+}
+
+// parseScrypt parses a PHC encoded scrypt hash.
+func parseScrypt(hash string) (p Scrypt, salt, key []byte, err error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 5 || parts[0] != "" || parts[1] != "scrypt" {
+		return p, nil, nil, ErrUnknownHash
+	}
+	_, err = fmt.Sscanf(parts[2], "ln=%d,r=%d,p=%d", &p.LogN, &p.R, &p.P)
+	if err != nil || !p.valid() {
+		return p, nil, nil, fmt.Errorf("scrypt: invalid parameters %q", parts[2])
+	}
+	salt, err = b64.DecodeString(parts[3])
+	if err == nil && len(salt) < minHashSaltLen {
+		err = errors.New("too short")
+	}
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("scrypt: invalid salt: %v", err)
+	}
+	key, err = b64.DecodeString(parts[4])
+	if err == nil && len(key) < minHashKeyLen {
+		err = errors.New("too short")
+	}
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("scrypt: invalid hash: %v", err)
+	}
+	p.SaltLen = len(salt)
+	p.KeyLen = len(key)
+	return p, salt, key, nil
+}
@@ -0,0 +1,237 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Cheap hashers for tests.
+var (
+	testArgon2id = Argon2id{Memory: 64, Time: 1}
+	testBcrypt   = Bcrypt{Cost: bcrypt.MinCost}
+	testScrypt   = Scrypt{LogN: 4}
+)
+
+func TestHashers(t *testing.T) {
+	for _, h := range []Hasher{testArgon2id, testBcrypt, testScrypt, Bcrypt{Cost: bcrypt.MinCost, PreHash: true}} {
+		hash, err := h.Hash("MyP/|$$W0rd")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ok, err := h.Compare("MyP/|$$W0rd", hash)
+		if err != nil || !ok {
+			t.Fatalf("%T: expected match, got %v, %v", h, ok, err)
+		}
+		ok, err = h.Compare("MyP/|$$W0rD", hash)
+		if err != nil || ok {
+			t.Fatalf("%T: expected no match, got %v, %v", h, ok, err)
+		}
+		hash2, err := h.Hash("MyP/|$$W0rd")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hash == hash2 {
+			t.Fatalf("%T: expected different salts", h)
+		}
+	}
+	hash, err := Argon2id{}.Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=19456,t=2,p=1$") {
+		t.Fatal("unexpected hash", hash)
+	}
+	if _, err := testArgon2id.Compare("password", "$2a$10$abc"); err != ErrUnknownHash {
+		t.Fatal("expected ErrUnknownHash, got", err)
+	}
+	if _, err := testBcrypt.Compare("password", hash); err != ErrUnknownHash {
+		t.Fatal("expected ErrUnknownHash, got", err)
+	}
+}
+
+func TestMalformedHashes(t *testing.T) {
+	salt := "c2FsdHNhbHRzYWx0c2FsdA"          // 16 bytes
+	key := "a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5" // 24 bytes
+	for _, hash := range []string{
+		"$scrypt$ln=4,r=8,p=1$" + salt + "$",
+		"$scrypt$ln=4,r=8,p=1$" + salt + "$a2V5",
+		"$scrypt$ln=4,r=8,p=1$$" + key,
+		"$scrypt$ln=4,r=0,p=1$" + salt + "$" + key,
+		"$scrypt$ln=4,r=8,p=0$" + salt + "$" + key,
+		"$scrypt$ln=31,r=8,p=1$" + salt + "$" + key,
+		"$scrypt$ln=4,r=8,p=1073741823$" + salt + "$" + key,
+		"$argon2id$v=19$m=64,t=1,p=1$" + salt + "$",
+		"$argon2id$v=19$m=64,t=1,p=1$" + salt + "$a2V5",
+		"$argon2id$v=19$m=64,t=1,p=1$$" + key,
+		"$argon2id$v=19$m=64,t=0,p=1$" + salt + "$" + key,
+		"$argon2id$v=19$m=64,t=1,p=0$" + salt + "$" + key,
+		"$argon2id$v=19$m=4,t=1,p=1$" + salt + "$" + key,
+		"$argon2id$v=19$m=4294967295,t=1,p=1$" + salt + "$" + key,
+		// Hostile parameters that would use too much memory or CPU.
+		"$scrypt$ln=24,r=8,p=1$" + salt + "$" + key,
+		"$scrypt$ln=4,r=8,p=17$" + salt + "$" + key,
+		"$scrypt$ln=20,r=8,p=16$" + salt + "$" + key,
+		"$argon2id$v=19$m=1048577,t=1,p=1$" + salt + "$" + key,
+		"$argon2id$v=19$m=64,t=1,p=17$" + salt + "$" + key,
+		"$argon2id$v=19$m=64,t=4294967295,p=1$" + salt + "$" + key,
+		"$argon2id$v=19$m=1048576,t=5,p=1$" + salt + "$" + key,
+		"$bcrypt-hmac-sha256$c2FsdA$2a$04$abcdefghijklmnopqrstuu5bIJ6fzrIBUkCHbJ.kNcaNFnyn9PzVG",
+		"$bcrypt-hmac-sha256$!!!$2a$04$abcdefghijklmnopqrstuu5bIJ6fzrIBUkCHbJ.kNcaNFnyn9PzVG",
+	} {
+		ok, err := Verify("anything at all", hash, nil)
+		if ok || err == nil || err == ErrUnknownHash {
+			t.Fatalf("%s: expected parse error, got %v, %v", hash, ok, err)
+		}
+		for _, h := range []Hasher{Argon2id{}, Scrypt{}, Bcrypt{}, Bcrypt{PreHash: true}} {
+			if !NeedsRehash(hash, h) {
+				t.Fatalf("%s: expected rehash with %T", hash, h)
+			}
+		}
+	}
+
+	// Hashers do not create hashes that cannot be verified.
+	for _, h := range []Hasher{
+		Argon2id{Memory: 2 << 20},
+		Argon2id{Threads: 32},
+		Argon2id{Memory: 1 << 20, Time: 5},
+		Scrypt{LogN: 24},
+		Scrypt{LogN: 4, P: 17},
+	} {
+		if _, err := h.Hash("password"); err == nil {
+			t.Fatalf("%+v: expected error", h)
+		}
+	}
+	// The valid form of the hashes can be compared.
+	for _, hash := range []string{
+		"$scrypt$ln=4,r=8,p=1$" + salt + "$" + key,
+		"$argon2id$v=19$m=64,t=1,p=1$" + salt + "$" + key,
+	} {
+		ok, err := Verify("anything at all", hash, nil)
+		if ok || err != nil {
+			t.Fatalf("%s: expected no match, got %v, %v", hash, ok, err)
+		}
+	}
+}
+
+func TestHashVerify(t *testing.T) {
+	for _, h := range []Hasher{testArgon2id, testBcrypt, testScrypt} {
+		hash, err := Hash("  \u00c5ngstr\u00f6m Password ", nil, h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The password is sanitized before it is verified,
+		// so decomposed input matches.
+		ok, err := Verify("A\u030angstro\u0308m Password", hash, nil)
+		if err != nil || !ok {
+			t.Fatalf("%T: expected match, got %v, %v", h, ok, err)
+		}
+		ok, err = Verify("Angstrom Password", hash, nil)
+		if err != nil || ok {
+			t.Fatalf("%T: expected no match, got %v, %v", h, ok, err)
+		}
+		ok, err = Verify("short", hash, nil)
+		if !errors.Is(err, ErrSanitizeTooShort) || ok {
+			t.Fatalf("%T: expected ErrSanitizeTooShort, got %v, %v", h, ok, err)
+		}
+		if NeedsRehash(hash, h) {
+			t.Fatalf("%T: hash %s should not need rehash", h, hash)
+		}
+		if !NeedsRehash(hash, nil) {
+			t.Fatalf("%T: hash %s should need rehash with the default hasher", h, hash)
+		}
+	}
+	if _, err := Verify("password", "$md5$abc", nil); err != ErrUnknownHash {
+		t.Fatal("expected ErrUnknownHash, got", err)
+	}
+	if _, err := Hash("short", nil, nil); !errors.Is(err, ErrSanitizeTooShort) {
+		t.Fatal("expected ErrSanitizeTooShort, got", err)
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	hash, err := testArgon2id.Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !(Argon2id{Memory: 128, Time: 1}).NeedsRehash(hash) {
+		t.Fatal("expected rehash with more memory")
+	}
+	hash, err = testScrypt.Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !(Scrypt{LogN: 5}).NeedsRehash(hash) {
+		t.Fatal("expected rehash with higher cost")
+	}
+	hash, err = testBcrypt.Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !(Bcrypt{Cost: bcrypt.MinCost + 1}).NeedsRehash(hash) {
+		t.Fatal("expected rehash with higher cost")
+	}
+	if !(Bcrypt{Cost: bcrypt.MinCost, PreHash: true}).NeedsRehash(hash) {
+		t.Fatal("expected rehash with pre-hashing")
+	}
+}
+
+func TestBcryptLong(t *testing.T) {
+	long := strings.Repeat("æ", 40) // 80 bytes
+	if _, err := Hash(long, nil, testBcrypt); err != ErrBcryptTooLong {
+		t.Fatal("expected ErrBcryptTooLong, got", err)
+	}
+	h := Bcrypt{Cost: bcrypt.MinCost, PreHash: true}
+	hash, err := Hash(long, nil, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$bcrypt-hmac-sha256$") || !strings.Contains(hash, "$2a$04$") {
+		t.Fatal("unexpected hash", hash)
+	}
+	ok, err := Verify(long, hash, nil)
+	if err != nil || !ok {
+		t.Fatalf("expected match, got %v, %v", ok, err)
+	}
+	// Only differs after 72 bytes.
+	ok, err = Verify(long[:len(long)-2]+"ø", hash, nil)
+	if err != nil || ok {
+		t.Fatalf("expected no match, got %v, %v", ok, err)
+	}
+}
+
+func TestBcryptPreHash(t *testing.T) {
+	h := Bcrypt{Cost: bcrypt.MinCost, PreHash: true}
+	hash, err := h.Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	salt, bhash, err := splitBcrypt(hash)
+	if err != nil || len(salt) != 16 || !isBcrypt(bhash) {
+		t.Fatalf("unexpected split of %s: %v, %q, %v", hash, salt, bhash, err)
+	}
+	// The bcrypt hash is not of the unsalted SHA-256 of the password.
+	sum := sha256.Sum256([]byte("password"))
+	if bcrypt.CompareHashAndPassword([]byte(bhash), []byte(base64.StdEncoding.EncodeToString(sum[:]))) == nil {
+		t.Fatal("bcrypt hash matches unsalted SHA-256")
+	}
+	// A different HMAC salt does not match.
+	other := bcryptHMAC + b64.EncodeToString([]byte("othersaltothersa")) + bhash
+	if ok, err := Verify("password", other, nil); ok || err != nil {
+		t.Fatalf("expected no match, got %v, %v", ok, err)
+	}
+	// Hashes in the passlib bcrypt-sha256 format are not recognized.
+	passlib := "$bcrypt-sha256$v=2,t=2b,r=12$n79VH.0Q2TMWmt3Oqt9uku$Kq4Noyk3094Y2QlB8NdRT8SvGiI4ft2"
+	if _, err := Verify("password", passlib, nil); err != ErrUnknownHash {
+		t.Fatal("expected ErrUnknownHash, got", err)
+	}
+	if !h.NeedsRehash(passlib) {
+		t.Fatal("expected rehash of passlib hash")
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import "errors"
+
+// ErrPasswordReused is returned by History.CheckHistory if the
+// password has been used by the user before.
+var ErrPasswordReused = errors.New("password has been used before")
+
+// A HistoryStore stores hashes of the previous passwords of users.
+// See the testdb and boltpw packages for implementations.
+type HistoryStore interface {
+	// Get returns the stored hashes for the user, newest first.
+	// If the user has no history, an empty slice and no error is returned.
+	Get(userID string) ([]string, error)
+
+	// Push adds a hash for the user, and removes the oldest hashes,
+	// so at most depth hashes are kept.
+	Push(userID, hash string, depth int) error
+}
+
+// History can be used to prevent users from reusing
+// their previous passwords.
+//
+// Passwords are sanitized using the same Sanitizer as when checking
+// and storing passwords, and only hashes are stored.
+type History struct {
+	// Store where the hashes are kept.
+	Store HistoryStore
+
+	// Depth is the number of previous passwords kept per user.
+	// If 0, 5 is used.
+	Depth int
+
+	// Sanitizer applied before hashing.
+	// If nil, DefaultSanitizer will be used.
+	Sanitizer Sanitizer
+
+	// Hasher used for hashing the passwords.
+	// If nil, DefaultHasher is used.
+	Hasher Hasher
+}
+
+// NewHistory returns a History keeping depth passwords
+// per user in the store.
+func NewHistory(store HistoryStore, depth int) *History {
+	return &History{Store: store, Depth: depth}
+}
+
+func (h *History) depth() int {
+	if h.Depth <= 0 {
+		return 5
+	}
+	return h.Depth
+}
+
+func (h *History) hasher() Hasher {
+	if h.Hasher == nil {
+		return DefaultHasher
+	}
+	return h.Hasher
+}
+
+func (h *History) sanitize(password string) (string, error) {
+	san := h.Sanitizer
+	if san == nil {
+		san = DefaultSanitizer
+	}
+	return san.Sanitize(password)
+}
+
+// CheckHistory will check if the password is among the previous
+// passwords of the user.
+// It will return an error if:
+//   - Sanitazition fails.
+//   - The store returns an error.
+//   - The password has been used before (ErrPasswordReused).
+//
+// Each stored hash is compared using the Hasher matching its format,
+// so the history stays valid when the Hasher is changed.
+// Hashes of unknown formats are compared using the Hasher of h.
+//
+// Since each stored hash must be compared, this can take a while
+// with slow hashes and a large depth.
+func (h *History) CheckHistory(userID, password string) error {
+	p, err := h.sanitize(password)
+	if err != nil {
+		return err
+	}
+	hashes, err := h.Store.Get(userID)
+	if err != nil {
+		return err
+	}
+	if len(hashes) > h.depth() {
+		hashes = hashes[:h.depth()]
+	}
+	for _, hash := range hashes {
+		// Older hashes may have been created by another Hasher.
+		hasher := hasherFor(hash)
+		if hasher == nil {
+			hasher = h.hasher()
+		}
+		ok, err := hasher.Compare(p, hash)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return &CheckError{Reason: ReasonReused, Err: ErrPasswordReused}
+		}
+	}
+	return nil
+}
+
+// Record will add the password to the history of the user.
+// Call this when the user changes password.
+func (h *History) Record(userID, password string) error {
+	p, err := h.sanitize(password)
+	if err != nil {
+		return err
+	}
+	hash, err := h.hasher().Hash(p)
+	if err != nil {
+		return err
+	}
+	return h.Store.Push(userID, hash, h.depth())
+}
@@ -0,0 +1,53 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/klauspost/password/drivers/testdb"
+)
+
+func TestHistory(t *testing.T) {
+	h := NewHistory(testdb.NewMemDB(), 2)
+	h.Hasher = testBcrypt
+	for _, pw := range []string{"password1", "password2", "password3"} {
+		err := h.Record("user", pw)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The oldest password is forgotten.
+	err := h.CheckHistory("user", "password1")
+	if err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	for _, pw := range []string{"password2", " password3"} {
+		err := h.CheckHistory("user", pw)
+		if !errors.Is(err, ErrPasswordReused) || ReasonOf(err) != ReasonReused {
+			t.Fatalf("%q: expected ErrPasswordReused, got %v", pw, err)
+		}
+	}
+	// Passwords are case sensitive.
+	if err := h.CheckHistory("user", "PASSWORD3"); err != nil {
+		t.Fatal("unexpected error", err)
+	}
+	if err := h.CheckHistory("user", "short"); !errors.Is(err, ErrSanitizeTooShort) {
+		t.Fatal("expected ErrSanitizeTooShort, got", err)
+	}
+	if err := h.Record("user", "short"); !errors.Is(err, ErrSanitizeTooShort) {
+		t.Fatal("expected ErrSanitizeTooShort, got", err)
+	}
+
+	// Changing the Hasher keeps the history.
+	h.Hasher = testArgon2id
+	if err := h.Record("user", "password4"); err != nil {
+		t.Fatal(err)
+	}
+	for _, pw := range []string{"password3", "password4"} {
+		if err := h.CheckHistory("user", pw); !errors.Is(err, ErrPasswordReused) {
+			t.Fatalf("%q: expected ErrPasswordReused, got %v", pw, err)
+		}
+	}
+}
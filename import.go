@@ -0,0 +1,457 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"context"
+	"io"
+	"log"
+	"sync"
+	"time"
+)
+
+type initer interface {
+	Init() error
+}
+
+// ImportOptions can be used to control an import.
+// The zero value will give the same behaviour as Import.
+type ImportOptions struct {
+	// Sanitizer used to clean up the passwords.
+	// If nil, DefaultSanitizer will be used.
+	Sanitizer Sanitizer
+
+	// BatchSize is the maximum number of passwords sent at once
+	// to a BulkWriter. If 0, BulkMax is used.
+	BatchSize int
+
+	// Logger used for progress output.
+	// If nil, the package Logger is used.
+	// To disable output, use a logger that writes to ioutil.Discard.
+	Logger *log.Logger
+
+	// ProgressEvery is the number of entries read between progress
+	// updates. If 0, progress is reported every 10000 entries.
+	ProgressEvery int
+
+	// OnProgress is called with the current statistics at every
+	// progress update, and when the import has finished.
+	OnProgress func(ImportStats)
+
+	// Workers is the number of goroutines sanitizing input.
+	// If 0 or 1, input is sanitized on the calling goroutine.
+	// If more than 1, the Sanitizer must be safe for concurrent use
+	// and the order of the entries sent to the writer is not preserved.
+	Workers int
+
+	// Writers is the maximum number of batches sent to a BulkWriter
+	// at the same time. This is only used if the writer implements
+	// ConcurrentBulkWriter and reports that it is safe. If 0, 1 is used.
+	Writers int
+
+	// Confusables will fold common homoglyphs of Latin letters in
+	// the passwords, so passwords that look the same are stored as
+	// the same value. See FoldHomoglyphs. CheckOptions.Confusables must be set
+	// when checking passwords against the database.
+	Confusables bool
+
+	// Folding is used to fold the case of the passwords.
+	// The same Folding must be used when checking passwords.
+	// If nil, FoldLower is used.
+	Folding Folding
+}
+
+// ImportStats contains statistics about an import or a removal.
+//
+// Duplicates only counts consecutive duplicates, which are still
+// sent to the writer. With more than one worker the order of
+// entries is not preserved, so fewer duplicates may be counted.
+type ImportStats struct {
+	Read       int            // Entries read from the Tokenizer.
+	Added      int            // Entries sent to the writer. Always 0 for a removal.
+	Removed    int            // Entries sent to the remover. Always 0 for an import.
+	Duplicates int            // Entries sent that were identical to the entry sent before them.
+	Rejected   map[string]int // Entries rejected by the sanitizer, by error message.
+	Folding    string         // Name of the Folding used.
+	Elapsed    time.Duration  // Time spent on the import.
+	Throughput float64        // Entries read per second.
+}
+
+// TotalRejected returns the number of entries rejected by the sanitizer.
+func (s ImportStats) TotalRejected() int {
+	n := 0
+	for _, v := range s.Rejected {
+		n += v
+	}
+	return n
+}
+
+// snapshot returns a copy of the stats with updated timing.
+func (s ImportStats) snapshot(start time.Time) ImportStats {
+	s.Elapsed = time.Since(start)
+	if secs := s.Elapsed.Seconds(); secs > 0 {
+		s.Throughput = float64(s.Read) / secs
+	}
+	rejected := make(map[string]int, len(s.Rejected))
+	for k, v := range s.Rejected {
+		rejected[k] = v
+	}
+	s.Rejected = rejected
+	return s
+}
+
+// Import will populate a database with common passwords.
+//
+// You must supply a Tokenizer (see tokenizer package for default tokenizers)
+// that will deliver the passwords,
+// a DbWriter, where the passwords will be sent,
+// and finally a Sanitizer to clean up the passwords -
+// - if you send nil DefaultSanitizer will be used.
+func Import(in Tokenizer, out DbWriter, san Sanitizer) error {
+	return ImportContext(context.Background(), in, out, san)
+}
+
+// ImportContext is the same as Import, but the import can be
+// cancelled using the supplied context.
+//
+// If the context is cancelled, the import is stopped, any batch that
+// is being written is allowed to finish or abort, and ctx.Err() is returned.
+// Writers implementing DbWriterContext or BulkWriterContext will receive
+// the context.
+func ImportContext(ctx context.Context, in Tokenizer, out DbWriter, san Sanitizer) error {
+	_, err := ImportWithOptionsContext(ctx, in, out, ImportOptions{Sanitizer: san})
+	return err
+}
+
+// ImportWithOptions will populate a database with common passwords,
+// like Import, but with settings supplied in opts.
+//
+// Statistics of the import are returned, also if the import fails.
+// Since no package global values are modified, several imports
+// can run at the same time.
+func ImportWithOptions(in Tokenizer, out DbWriter, opts ImportOptions) (ImportStats, error) {
+	return ImportWithOptionsContext(context.Background(), in, out, opts)
+}
+
+// ImportWithOptionsContext is the same as ImportWithOptions,
+// but the import can be cancelled using the supplied context.
+// See ImportContext.
+func ImportWithOptionsContext(ctx context.Context, in Tokenizer, out DbWriter, opts ImportOptions) (ImportStats, error) {
+	return runImport(ctx, in, out, opts, false)
+}
+
+// runImport sends the sanitized passwords of in to out.
+// If remove is set, out removes the passwords,
+// and they are counted and logged as removed.
+func runImport(ctx context.Context, in Tokenizer, out DbWriter, opts ImportOptions, remove bool) (stats ImportStats, err error) {
+	san := opts.Sanitizer
+	if san == nil {
+		san = DefaultSanitizer
+	}
+	batch := opts.BatchSize
+	if batch <= 0 {
+		batch = BulkMax
+	}
+	imp := importer{
+		ctx:        ctx,
+		stats:      &stats,
+		logger:     opts.Logger,
+		every:      opts.ProgressEvery,
+		onProgress: opts.OnProgress,
+		start:      time.Now(),
+		confusable: opts.Confusables,
+		fold:       opts.Folding,
+		remove:     remove,
+	}
+	if imp.fold == nil {
+		imp.fold = FoldLower
+	}
+	if imp.logger == nil {
+		imp.logger = Logger
+	}
+	if imp.every <= 0 {
+		imp.every = 10000
+	}
+
+	stats.Rejected = make(map[string]int)
+	stats.Folding = imp.fold.Name()
+	defer func() {
+		stats = stats.snapshot(imp.start)
+	}()
+
+	bulk, ok := out.(BulkWriter)
+	if ok {
+		initer, ok := out.(initer)
+		if ok {
+			err := initer.Init()
+			if err != nil {
+				return stats, err
+			}
+		}
+		closer, ok := out.(io.Closer)
+		if ok {
+			defer func() {
+				e := closer.Close()
+				if e != nil && err == nil {
+					err = e
+				}
+			}()
+		}
+		writers := 1
+		if cw, ok := out.(ConcurrentBulkWriter); ok && opts.Writers > 1 && cw.ConcurrentSafe() {
+			writers = opts.Writers
+		}
+		out = bulkWrap(ctx, bulk, batch, writers)
+	}
+
+	initer, ok := out.(initer)
+	if ok {
+		err := initer.Init()
+		if err != nil {
+			return stats, err
+		}
+	}
+
+	closer, ok := out.(io.Closer)
+	if ok {
+		defer func() {
+			e := closer.Close()
+			if e != nil && err == nil {
+				err = e
+			}
+		}()
+	}
+	imp.out = out
+
+	if opts.Workers > 1 {
+		err = imp.parallel(in, san, opts.Workers)
+	} else {
+		err = imp.sequential(in, san)
+	}
+	if err != nil {
+		return stats, err
+	}
+	s := stats.snapshot(imp.start)
+	imp.logger.Printf("Processing took %s, processing %d entries.\n", s.Elapsed, s.Read)
+	if remove {
+		imp.logger.Printf("Removed %d entries.\n", s.Removed)
+	}
+	imp.logger.Printf("%0.2f entries/sec.", s.Throughput)
+	if imp.onProgress != nil {
+		imp.onProgress(s)
+	}
+	return stats, nil
+}
+
+// importer keeps the state of a running import.
+type importer struct {
+	ctx        context.Context
+	out        DbWriter
+	stats      *ImportStats
+	logger     *log.Logger
+	every      int
+	onProgress func(ImportStats)
+	start      time.Time
+	prev       string
+	confusable bool
+	fold       Folding
+	remove     bool // Entries are removed instead of added.
+}
+
+// sent returns the number of entries sent to the writer.
+func (imp *importer) sent() *int {
+	if imp.remove {
+		return &imp.stats.Removed
+	}
+	return &imp.stats.Added
+}
+
+// add will send a sanitized value to the writer.
+func (imp *importer) add(v string) error {
+	err := add(imp.ctx, imp.out, v)
+	if err != nil {
+		return err
+	}
+	n := imp.sent()
+	if *n > 0 && v == imp.prev {
+		imp.stats.Duplicates++
+	}
+	imp.prev = v
+	*n++
+	return nil
+}
+
+// read will register that n entries has been read,
+// and report progress if needed.
+func (imp *importer) read(n int) {
+	before := imp.stats.Read / imp.every
+	imp.stats.Read += n
+	if imp.stats.Read/imp.every == before {
+		return
+	}
+	s := imp.stats.snapshot(imp.start)
+	if imp.remove {
+		imp.logger.Printf("Read %d, (%0.0f per sec). Removed: %d (%d%%)\n", s.Read, s.Throughput, s.Removed, (s.Removed*100)/s.Read)
+	} else {
+		imp.logger.Printf("Read %d, (%0.0f per sec). Added: %d (%d%%)\n", s.Read, s.Throughput, s.Added, (s.Added*100)/s.Read)
+	}
+	if imp.onProgress != nil {
+		imp.onProgress(s)
+	}
+}
+
+// sequential will read, sanitize and add entries on the calling goroutine.
+func (imp *importer) sequential(in Tokenizer, san Sanitizer) error {
+	done := imp.ctx.Done()
+	for {
+		select {
+		case <-done:
+			return imp.ctx.Err()
+		default:
+		}
+		record, err := in.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		valstring, err := san.Sanitize(record)
+		if err == nil {
+			err = imp.add(dbKey(valstring, imp.fold, imp.confusable))
+			if err != nil {
+				return err
+			}
+		} else {
+			imp.stats.Rejected[err.Error()]++
+		}
+		imp.read(1)
+	}
+}
+
+// importChunk is the number of entries sent to a worker at once.
+const importChunk = 256
+
+// sanitized is the result of sanitizing a chunk of entries.
+type sanitized struct {
+	read     int
+	values   []string
+	rejected map[string]int
+}
+
+func sanitizeChunk(san Sanitizer, records []string, fold Folding, confusables bool) sanitized {
+	res := sanitized{read: len(records), values: make([]string, 0, len(records))}
+	for _, record := range records {
+		valstring, err := san.Sanitize(record)
+		if err != nil {
+			if res.rejected == nil {
+				res.rejected = make(map[string]int)
+			}
+			res.rejected[err.Error()]++
+			continue
+		}
+		res.values = append(res.values, dbKey(valstring, fold, confusables))
+	}
+	return res
+}
+
+// parallel will read entries on a separate goroutine, and sanitize them
+// using the specified number of workers.
+// Entries are sent to the writer on the calling goroutine.
+// The order of the entries sent to the writer is not preserved.
+func (imp *importer) parallel(in Tokenizer, san Sanitizer, workers int) error {
+	ctx, cancel := context.WithCancel(imp.ctx)
+	defer cancel()
+
+	jobs := make(chan []string, workers)
+	results := make(chan sanitized, workers)
+	readErr := make(chan error, 1)
+	readDone := make(chan struct{})
+
+	// Reader
+	go func() {
+		defer close(readDone)
+		defer close(jobs)
+		for {
+			records := make([]string, 0, importChunk)
+			var err error
+			for len(records) < importChunk {
+				var record string
+				record, err = in.Next()
+				if err != nil {
+					break
+				}
+				records = append(records, record)
+			}
+			if len(records) > 0 {
+				select {
+				case jobs <- records:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				readErr <- err
+				return
+			}
+		}
+	}()
+
+	// Sanitizers
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for records := range jobs {
+				select {
+				case results <- sanitizeChunk(san, records, imp.fold, imp.confusable):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var err error
+	for res := range results {
+		if err != nil {
+			// Drain until all workers have exited.
+			continue
+		}
+		for k, v := range res.rejected {
+			imp.stats.Rejected[k] += v
+		}
+		for _, v := range res.values {
+			err = imp.add(v)
+			if err != nil {
+				break
+			}
+		}
+		if err != nil {
+			cancel()
+			continue
+		}
+		imp.read(res.read)
+	}
+	<-readDone
+	if err != nil {
+		return err
+	}
+	if err := imp.ctx.Err(); err != nil {
+		return err
+	}
+	select {
+	case err = <-readErr:
+	default:
+	}
+	return err
+}
@@ -0,0 +1,221 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/klauspost/password/drivers/testdb"
+	"github.com/klauspost/password/testdata"
+	"github.com/klauspost/password/tokenizer"
+)
+
+// sliceTokenizer returns the strings in order.
+type sliceTokenizer []string
+
+func (s *sliceTokenizer) Next() (string, error) {
+	if len(*s) == 0 {
+		return "", io.EOF
+	}
+	v := (*s)[0]
+	*s = (*s)[1:]
+	return v, nil
+}
+
+// countWriter records the size of batches sent to it.
+type countWriter struct {
+	batches []int
+}
+
+func (c *countWriter) Add(s string) error {
+	c.batches = append(c.batches, 1)
+	return nil
+}
+
+func (c *countWriter) AddMultiple(s []string) error {
+	c.batches = append(c.batches, len(s))
+	return nil
+}
+
+func TestImportWithOptions(t *testing.T) {
+	in := sliceTokenizer{"password1", "Password1", "short", "password2", "tiny", "password3", "password4", "password5"}
+	out := &countWriter{}
+	var logged bytes.Buffer
+	progress := 0
+	stats, err := ImportWithOptions(&in, out, ImportOptions{
+		BatchSize:     2,
+		Logger:        log.New(&logged, "", 0),
+		ProgressEvery: 3,
+		OnProgress:    func(ImportStats) { progress++ },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Read != 8 {
+		t.Fatal("expected 8 read, got", stats.Read)
+	}
+	if stats.Added != 6 {
+		t.Fatal("expected 6 added, got", stats.Added)
+	}
+	if stats.Duplicates != 1 {
+		t.Fatal("expected 1 duplicate, got", stats.Duplicates)
+	}
+	if n := stats.Rejected[ErrSanitizeTooShort.Error()]; n != 2 {
+		t.Fatal("expected 2 too short, got", n)
+	}
+	if stats.TotalRejected() != 2 {
+		t.Fatal("expected 2 rejected, got", stats.TotalRejected())
+	}
+	if len(out.batches) != 3 || out.batches[0] != 2 || out.batches[2] != 2 {
+		t.Fatal("unexpected batches:", out.batches)
+	}
+	// Two progress updates and the final one.
+	if progress != 3 {
+		t.Fatal("expected 3 progress updates, got", progress)
+	}
+	if !strings.Contains(logged.String(), "Processing took") {
+		t.Fatal("expected output on logger, got", logged.String())
+	}
+}
+
+func TestImportWithOptionsTestdata(t *testing.T) {
+	buf, err := testdata.Asset("testdata.txt.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mem := testdb.NewMemDBBulk()
+	in, err := tokenizer.NewGzLine(bytes.NewBuffer(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, err := ImportWithOptions(in, mem, ImportOptions{BatchSize: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Read != stats.Added+stats.TotalRejected() {
+		t.Fatalf("stats do not add up: %+v", stats)
+	}
+	if len(*mem) > stats.Added {
+		t.Fatalf("database has %d entries, but only %d added", len(*mem), stats.Added)
+	}
+}
+
+func TestImportParallel(t *testing.T) {
+	buf, err := testdata.Asset("testdata.txt.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := testdb.NewMemDB()
+	in, err := tokenizer.NewGzLine(bytes.NewBuffer(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Import(in, want, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := testdb.NewMemDBBulk()
+	in, err = tokenizer.NewGzLine(bytes.NewBuffer(buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats, err := ImportWithOptions(in, got, ImportOptions{Workers: 4, BatchSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*got) != len(*want) {
+		t.Fatalf("parallel import has %d entries, sequential has %d", len(*got), len(*want))
+	}
+	for k := range *want {
+		if _, ok := (*got)[k]; !ok {
+			t.Fatalf("parallel import is missing %q", k)
+		}
+	}
+	if stats.Read != stats.Added+stats.TotalRejected() {
+		t.Fatalf("stats do not add up: %+v", stats)
+	}
+}
+
+// concurrentWriter is a concurrency safe writer that records
+// the maximum number of batches written at once.
+type concurrentWriter struct {
+	mu       sync.Mutex
+	active   int
+	max      int
+	n        int
+	failAt   int
+	received map[string]struct{}
+}
+
+func (c *concurrentWriter) ConcurrentSafe() bool {
+	return true
+}
+
+func (c *concurrentWriter) Add(s string) error {
+	return c.AddMultiple([]string{s})
+}
+
+func (c *concurrentWriter) AddMultiple(s []string) error {
+	c.mu.Lock()
+	c.active++
+	c.n++
+	n := c.n
+	if c.active > c.max {
+		c.max = c.active
+	}
+	for _, v := range s {
+		c.received[v] = struct{}{}
+	}
+	c.mu.Unlock()
+	time.Sleep(time.Millisecond)
+	c.mu.Lock()
+	c.active--
+	c.mu.Unlock()
+	if n == c.failAt {
+		return errWrite
+	}
+	return nil
+}
+
+func TestImportConcurrentWriters(t *testing.T) {
+	out := &concurrentWriter{received: make(map[string]struct{})}
+	in := endlessTokenizer(0)
+	stats, err := ImportWithOptions(&limitTokenizer{in: &in, n: 1000}, out, ImportOptions{BatchSize: 10, Writers: 4, Workers: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.received) != 1000 || stats.Added != 1000 {
+		t.Fatalf("expected 1000 entries, got %d, added %d", len(out.received), stats.Added)
+	}
+	if out.max < 2 {
+		t.Fatal("expected concurrent writes, max was", out.max)
+	}
+
+	// The first error must be returned.
+	out = &concurrentWriter{received: make(map[string]struct{}), failAt: 5}
+	_, err = ImportWithOptions(new(endlessTokenizer), out, ImportOptions{BatchSize: 10, Writers: 4, Workers: 2})
+	if err != errWrite {
+		t.Fatal("expected write error, got", err)
+	}
+}
+
+// limitTokenizer returns n entries from in.
+type limitTokenizer struct {
+	in Tokenizer
+	n  int
+}
+
+func (l *limitTokenizer) Next() (string, error) {
+	if l.n == 0 {
+		return "", io.EOF
+	}
+	l.n--
+	return l.in.Next()
+}
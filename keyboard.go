@@ -0,0 +1,296 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"errors"
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// ErrKeyboardWalk is returned by KeyboardWalkValidator if too much of
+// the password consists of adjacent keys on a keyboard.
+var ErrKeyboardWalk = errors.New("password is a keyboard pattern")
+
+// KeyboardLayout describes the position of keys on a keyboard,
+// so adjacent keys can be found. Use NewKeyboardLayout to create one.
+type KeyboardLayout struct {
+	name string
+	keys map[rune]keyPos
+}
+
+// keyPos is the position of a key.
+type keyPos struct {
+	row int
+	col int
+	x   float64 // Horizontal position in keys, including row offset.
+}
+
+// NewKeyboardLayout creates a new keyboard layout.
+//
+// rows contains the characters of each row, from the top.
+// shifted contains the characters produced with shift held down,
+// at the same positions as rows. Rows in shifted must have the same
+// number of characters as the row in rows. Use the unshifted character
+// if a key has no shifted character. shifted may be nil.
+//
+// offsets contains the horizontal offset of each row, measured in keys.
+// If nil, the offsets of a standard keyboard with a key left of "1"
+// are used: 0, 1.5, 1.75 and 2.25.
+//
+// Keys are adjacent if they are next to each other on the same row,
+// or on neighbouring rows and less than one key apart horizontally.
+//
+// Characters are stored NFC composed. If the NFKD normalization of a
+// character composes to another single character, like "²" to "2",
+// that character is also mapped to the key, unless it is on another key.
+func NewKeyboardLayout(name string, rows, shifted []string, offsets []float64) (*KeyboardLayout, error) {
+	if offsets == nil {
+		offsets = []float64{0, 1.5, 1.75, 2.25}
+	}
+	if len(offsets) < len(rows) {
+		return nil, fmt.Errorf("keyboard %s: %d offsets for %d rows", name, len(offsets), len(rows))
+	}
+	if shifted != nil && len(shifted) != len(rows) {
+		return nil, fmt.Errorf("keyboard %s: %d shifted rows for %d rows", name, len(shifted), len(rows))
+	}
+	k := &KeyboardLayout{name: name, keys: make(map[rune]keyPos)}
+	var order []rune // Keys in the order they were added.
+	for row, chars := range rows {
+		col := 0
+		for _, r := range norm.NFC.String(chars) {
+			k.keys[r] = keyPos{row: row, col: col, x: offsets[row] + float64(col)}
+			order = append(order, r)
+			col++
+		}
+		if shifted == nil {
+			continue
+		}
+		srow := norm.NFC.String(shifted[row])
+		if n := utf8.RuneCountInString(srow); n != col {
+			return nil, fmt.Errorf("keyboard %s: shifted row %d has %d keys, expected %d", name, row, n, col)
+		}
+		col = 0
+		for _, r := range srow {
+			if _, ok := k.keys[r]; !ok {
+				k.keys[r] = keyPos{row: row, col: col, x: offsets[row] + float64(col)}
+				order = append(order, r)
+			}
+			col++
+		}
+	}
+	// Add the characters produced by compatibility normalization,
+	// after all keys, so they never replace a key.
+	compat := make(map[rune]keyPos)
+	for _, r := range order {
+		c := []rune(norm.NFC.String(norm.NFKD.String(string(r))))
+		if len(c) != 1 {
+			continue
+		}
+		_, isKey := k.keys[c[0]]
+		_, added := compat[c[0]]
+		if !isKey && !added {
+			compat[c[0]] = k.keys[r]
+		}
+	}
+	for r, p := range compat {
+		k.keys[r] = p
+	}
+	return k, nil
+}
+
+// mustKeyboard is used for the built-in layouts.
+func mustKeyboard(k *KeyboardLayout, err error) *KeyboardLayout {
+	if err != nil {
+		panic(err)
+	}
+	return k
+}
+
+// Built-in keyboard layouts.
+var (
+	QWERTY = mustKeyboard(NewKeyboardLayout("qwerty",
+		[]string{"`1234567890-=", "qwertyuiop[]\\", "asdfghjkl;'", "zxcvbnm,./"},
+		[]string{"~!@#$%^&*()_+", "QWERTYUIOP{}|", "ASDFGHJKL:\"", "ZXCVBNM<>?"},
+		nil))
+
+	QWERTZ = mustKeyboard(NewKeyboardLayout("qwertz",
+		[]string{"^1234567890ß´", "qwertzuiopü+", "asdfghjklöä#", "<yxcvbnm,.-"},
+		[]string{"°!\"§$%&/()=?`", "QWERTZUIOPÜ*", "ASDFGHJKLÖÄ'", ">YXCVBNM;:_"},
+		[]float64{0, 1.5, 1.75, 1.25}))
+
+	AZERTY = mustKeyboard(NewKeyboardLayout("azerty",
+		[]string{"²&é\"'(-è_çà)=", "azertyuiop^$", "qsdfghjklmù*", "<wxcvbn,;:!"},
+		[]string{"²1234567890°+", "AZERTYUIOP¨£", "QSDFGHJKLM%µ", ">WXCVBN?./§"},
+		[]float64{0, 1.5, 1.75, 1.25}))
+
+	Dvorak = mustKeyboard(NewKeyboardLayout("dvorak",
+		[]string{"`1234567890[]", "',.pyfgcrl/=\\", "aoeuidhtns-", ";qjkxbmwvz"},
+		[]string{"~!@#$%^&*(){}", "\"<>PYFGCRL?+|", "AOEUIDHTNS_", ":QJKXBMWVZ"},
+		nil))
+)
+
+// Name returns the name of the layout.
+func (k *KeyboardLayout) Name() string {
+	return k.name
+}
+
+// pos returns the position of r.
+func (k *KeyboardLayout) pos(r rune) (keyPos, bool) {
+	p, ok := k.keys[r]
+	if !ok {
+		p, ok = k.keys[unicode.ToLower(r)]
+	}
+	return p, ok
+}
+
+// Adjacent returns true if a and b are on neighbouring keys.
+func (k *KeyboardLayout) Adjacent(a, b rune) bool {
+	_, ok := k.step(a, b)
+	return ok
+}
+
+// keyStep is the direction of a step between adjacent keys.
+type keyStep struct {
+	row int // Change in row: -1, 0 or 1.
+	dir int // Horizontal direction: -1, 0 or 1.
+}
+
+// step returns the direction from a to b,
+// and whether they are on neighbouring keys.
+func (k *KeyboardLayout) step(a, b rune) (keyStep, bool) {
+	pa, ok := k.pos(a)
+	if !ok {
+		return keyStep{}, false
+	}
+	pb, ok := k.pos(b)
+	if !ok {
+		return keyStep{}, false
+	}
+	d := pb.x - pa.x
+	switch pb.row - pa.row {
+	case 0:
+		if pa.col-pb.col != 1 && pb.col-pa.col != 1 {
+			return keyStep{}, false
+		}
+	case 1, -1:
+		if d >= 1 || d <= -1 {
+			return keyStep{}, false
+		}
+	default:
+		return keyStep{}, false
+	}
+	s := keyStep{row: pb.row - pa.row}
+	switch {
+	case d > 0:
+		s.dir = 1
+	case d < 0:
+		s.dir = -1
+	}
+	return s, true
+}
+
+// walkCoverage returns the fraction of r that is covered by
+// walks of adjacent keys at least minRun keys long.
+//
+// A walk must keep its direction, or alternate between two directions
+// like "1q2w3e", and may not step back to the previous key.
+// Otherwise words like "werewolf" or "dresser", that move back and
+// forth between neighbouring keys, would be counted as walks.
+// When a walk changes direction, a new walk starts at the last key.
+func (k *KeyboardLayout) walkCoverage(r []rune, minRun int) float64 {
+	if len(r) == 0 {
+		return 0
+	}
+	covered := make([]bool, len(r))
+	start := 0
+	end := func(i int) {
+		if i-start >= minRun {
+			for j := start; j < i; j++ {
+				covered[j] = true
+			}
+		}
+	}
+	var steps []keyStep // Steps of the current walk.
+	for i := 1; i < len(r); i++ {
+		s, ok := k.step(r[i-1], r[i])
+		if !ok {
+			end(i)
+			start = i
+			steps = steps[:0]
+			continue
+		}
+		if n := len(steps); n > 0 {
+			prev := steps[n-1]
+			back := s == keyStep{row: -prev.row, dir: -prev.dir}
+			turn := n > 1 && s != prev && s != steps[n-2]
+			if back || turn {
+				end(i)
+				start = i - 1
+				steps = steps[:0]
+			}
+		}
+		steps = append(steps, s)
+	}
+	end(len(r))
+	n := 0
+	for _, c := range covered {
+		if c {
+			n++
+		}
+	}
+	return float64(n) / float64(len(r))
+}
+
+// KeyboardWalkValidator will reject passwords where a large part of the
+// password consists of walks of adjacent keys on a keyboard,
+// like "qwertyuiop", "1qaz2wsx" or "zxcvbnm,./".
+// Walks must keep their direction, or alternate between two
+// directions, so words that happen to use neighbouring keys,
+// like "werewolf", are not rejected.
+//
+// The validator returns the input unchanged, so it should be
+// used in a Chain after a sanitizer.
+// The password is NFC composed before it is checked, so walks over
+// accented keys are also found after NFKD normalization.
+// Rejected passwords will return a *CheckError with ReasonKeyboardWalk,
+// where Detail contains the name of the matching layout.
+type KeyboardWalkValidator struct {
+	// Layouts to check.
+	// If nil, QWERTY, QWERTZ, AZERTY and Dvorak are checked.
+	Layouts []*KeyboardLayout
+
+	// MaxCoverage is the maximum fraction (0 to 1) of the password
+	// that may be covered by keyboard walks. If 0, 0.5 is used.
+	MaxCoverage float64
+
+	// MinRun is the minimum number of adjacent keys
+	// counted as a walk. If 0, 4 is used.
+	MinRun int
+}
+
+// Sanitize will reject the password if it is covered too much by keyboard walks.
+func (v KeyboardWalkValidator) Sanitize(s string) (string, error) {
+	layouts := v.Layouts
+	if layouts == nil {
+		layouts = []*KeyboardLayout{QWERTY, QWERTZ, AZERTY, Dvorak}
+	}
+	maxCov := v.MaxCoverage
+	if maxCov == 0 {
+		maxCov = 0.5
+	}
+	minRun := v.MinRun
+	if minRun == 0 {
+		minRun = 4
+	}
+	r := []rune(norm.NFC.String(s))
+	for _, k := range layouts {
+		if k.walkCoverage(r, minRun) > maxCov {
+			return "", &CheckError{Reason: ReasonKeyboardWalk, Detail: k.name, Err: ErrKeyboardWalk}
+		}
+	}
+	return s, nil
+}
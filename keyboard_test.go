@@ -0,0 +1,135 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestKeyboardAdjacent(t *testing.T) {
+	tests := []struct {
+		k    *KeyboardLayout
+		a, b rune
+		adj  bool
+	}{
+		{QWERTY, 'q', 'w', true},
+		{QWERTY, 'Q', 'W', true},
+		{QWERTY, '1', 'q', true},
+		{QWERTY, 'q', 'a', true},
+		{QWERTY, 'a', 'z', true},
+		{QWERTY, '!', 'Q', true},
+		{QWERTY, 'q', 'e', false},
+		{QWERTY, 'q', 'z', false},
+		{QWERTY, 'z', '2', false},
+		{QWERTY, 'q', 'q', false},
+		{QWERTZ, 'y', 'x', true},
+		{QWERTZ, 't', 'z', true},
+		{AZERTY, 'a', 'z', true},
+		{AZERTY, 'w', 'x', true},
+		{Dvorak, 'a', 'o', true},
+		{Dvorak, 'a', 's', false},
+	}
+	for _, test := range tests {
+		if adj := test.k.Adjacent(test.a, test.b); adj != test.adj {
+			t.Errorf("%s: Adjacent(%q, %q): expected %v, got %v", test.k.Name(), test.a, test.b, test.adj, adj)
+		}
+	}
+}
+
+func TestKeyboardWalkValidator(t *testing.T) {
+	v := KeyboardWalkValidator{}
+	rejected := map[string]string{
+		"qwertyuiop":   "qwerty",
+		"1qaz2wsx3edc": "qwerty",
+		"zxcvbnm,./":   "qwerty",
+		"QWERTYUIOP":   "qwerty",
+		"!QAZ@WSX":     "qwerty",
+		"aoeuidhtns":   "dvorak",
+		"1q2w3e4r5t":   "qwerty",
+		"poiulkjh":     "qwerty",
+	}
+	for pw, layout := range rejected {
+		_, err := v.Sanitize(pw)
+		var ce *CheckError
+		if !errors.As(err, &ce) || !errors.Is(err, ErrKeyboardWalk) {
+			t.Errorf("%q: expected ErrKeyboardWalk, got %v", pw, err)
+			continue
+		}
+		if ce.Detail != layout {
+			t.Errorf("%q: expected layout %s, got %s", pw, layout, ce.Detail)
+		}
+	}
+	// Walks only found on a single layout.
+	single := map[string]*KeyboardLayout{
+		"6zuio":  QWERTZ,
+		"aqwxsz": AZERTY,
+	}
+	for pw, k := range single {
+		if _, err := v.Sanitize(pw); !errors.Is(err, ErrKeyboardWalk) {
+			t.Errorf("%q: expected ErrKeyboardWalk, got %v", pw, err)
+		}
+		if _, err := (KeyboardWalkValidator{Layouts: []*KeyboardLayout{QWERTY}}).Sanitize(pw); err != nil {
+			t.Errorf("%q: unexpected error on qwerty %v", pw, err)
+		}
+		_, err := (KeyboardWalkValidator{Layouts: []*KeyboardLayout{k}}).Sanitize(pw)
+		if !errors.Is(err, ErrKeyboardWalk) {
+			t.Errorf("%q: expected ErrKeyboardWalk on %s, got %v", pw, k.Name(), err)
+		}
+	}
+	// Dictionary words that move back and forth between neighbouring keys.
+	for _, pw := range []string{"werewolf", "dresser", "Frederick", "reweaved", "sweeter", "deserter"} {
+		if _, err := v.Sanitize(pw); err != nil {
+			t.Errorf("%q: unexpected error %v", pw, err)
+		}
+	}
+	for _, pw := range []string{"correct horse battery staple", "MyP/|$$W0rd", "Password1", "tl1992rell"} {
+		if _, err := v.Sanitize(pw); err != nil {
+			t.Errorf("%q: unexpected error %v", pw, err)
+		}
+	}
+}
+
+func TestKeyboardWalkSanitized(t *testing.T) {
+	// Accented keys are decomposed by DefaultSanitizer.
+	san := Chain(DefaultSanitizer, KeyboardWalkValidator{Layouts: []*KeyboardLayout{AZERTY}})
+	for _, pw := range []string{"&\u00e9\"'(-\u00e8_\u00e7\u00e0", "lm\u00f9*lm\u00f9*", "e\u0301\"'(-e\u0300_c\u0327a\u0300"} {
+		if _, err := san.Sanitize(pw); !errors.Is(err, ErrKeyboardWalk) {
+			t.Errorf("%q: expected ErrKeyboardWalk, got %v", pw, err)
+		}
+	}
+	san = Chain(DefaultSanitizer, KeyboardWalkValidator{Layouts: []*KeyboardLayout{QWERTZ}})
+	if _, err := san.Sanitize("\u00e4\u00f6lkjhgf"); !errors.Is(err, ErrKeyboardWalk) {
+		t.Error("expected ErrKeyboardWalk, got", err)
+	}
+	if _, err := san.Sanitize("correct horse"); err != nil {
+		t.Error("unexpected error", err)
+	}
+}
+
+func TestNewKeyboardLayout(t *testing.T) {
+	// A Nordic layout, with ø and æ next to l.
+	k, err := NewKeyboardLayout("nordic",
+		[]string{"1234567890", "qwertyuiopå", "asdfghjkløæ", "zxcvbnm,.-"},
+		[]string{"!\"#¤%&/()=", "QWERTYUIOPÅ", "ASDFGHJKLØÆ", "ZXCVBNM;:_"},
+		[]float64{0.5, 1, 1.25, 1.75})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !k.Adjacent('l', 'ø') || !k.Adjacent('Ø', 'Æ') || !k.Adjacent('å', 'æ') {
+		t.Fatal("expected nordic keys to be adjacent")
+	}
+	v := KeyboardWalkValidator{Layouts: []*KeyboardLayout{k}}
+	if _, err := v.Sanitize("jkløæ"); !errors.Is(err, ErrKeyboardWalk) {
+		t.Fatal("expected ErrKeyboardWalk, got", err)
+	}
+
+	_, err = NewKeyboardLayout("bad", []string{"abc"}, []string{"AB"}, nil)
+	if err == nil {
+		t.Fatal("expected error for mismatched shifted row")
+	}
+	_, err = NewKeyboardLayout("bad", []string{"abc", "def"}, nil, []float64{0})
+	if err == nil {
+		t.Fatal("expected error for missing offsets")
+	}
+}
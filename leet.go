@@ -0,0 +1,121 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import "unicode"
+
+// DefaultLeetMap contains common leetspeak substitutions.
+// Each character maps to the letters it may replace.
+var DefaultLeetMap = map[rune][]rune{
+	'@': {'a'},
+	'4': {'a'},
+	'8': {'b'},
+	'(': {'c'},
+	'3': {'e'},
+	'9': {'g'},
+	'6': {'g'},
+	'#': {'h'},
+	'1': {'l', 'i'},
+	'!': {'i', 'l'},
+	'|': {'l', 'i'},
+	'0': {'o'},
+	'$': {'s'},
+	'5': {'s'},
+	'7': {'t'},
+	'+': {'t'},
+	'2': {'z'},
+}
+
+// Leet is a VariantGenerator that will undo leetspeak substitutions,
+// so "p@$$w0rd" will also be looked up as "password".
+//
+// Each substituted character can also be left as it is, so "p@ssw0rd1"
+// will also be looked up as "password1".
+type Leet struct {
+	// Map contains the substitutions to undo.
+	// If nil, DefaultLeetMap is used.
+	Map map[rune][]rune
+
+	// MaxVariants is the maximum number of variants returned.
+	// If 0, 16 is used.
+	// Variants with all characters substituted are returned first,
+	// except for digits in runs of digits, like "2024", which are
+	// more likely to be numbers. These are left as they are in the
+	// first variants, and substituted last.
+	MaxVariants int
+}
+
+// Variants returns the variants of s with leetspeak substitutions undone.
+func (l Leet) Variants(s string) []string {
+	m := l.Map
+	if m == nil {
+		m = DefaultLeetMap
+	}
+	max := l.MaxVariants
+	if max <= 0 {
+		max = 16
+	}
+	r := []rune(s)
+
+	// Collect the positions that can be substituted, and the choices
+	// for each. Digits in runs are collected first, so they change
+	// last, with the original first. Other positions have the
+	// original last.
+	var pos []int
+	var choices [][]rune
+	for _, runs := range []bool{true, false} {
+		for i, c := range r {
+			subs, ok := m[c]
+			if !ok || len(subs) == 0 || inDigitRun(r, i) != runs {
+				continue
+			}
+			pos = append(pos, i)
+			if runs {
+				choices = append(choices, append([]rune{c}, subs...))
+			} else {
+				choices = append(choices, append(append([]rune{}, subs...), c))
+			}
+		}
+	}
+	if len(pos) == 0 {
+		return nil
+	}
+	orig := append([]rune{}, r...)
+
+	// Iterate all combinations, changing the last position first.
+	var res []string
+	idx := make([]int, len(pos))
+	for len(res) < max {
+		original := true
+		for j, p := range pos {
+			r[p] = choices[j][idx[j]]
+			if r[p] != orig[p] {
+				original = false
+			}
+		}
+		if !original {
+			res = append(res, string(r))
+		}
+
+		j := len(idx) - 1
+		for ; j >= 0; j-- {
+			idx[j]++
+			if idx[j] < len(choices[j]) {
+				break
+			}
+			idx[j] = 0
+		}
+		if j < 0 {
+			break
+		}
+	}
+	return res
+}
+
+// inDigitRun returns whether r[i] is a digit next to another digit.
+func inDigitRun(r []rune, i int) bool {
+	if !unicode.IsDigit(r[i]) {
+		return false
+	}
+	return (i > 0 && unicode.IsDigit(r[i-1])) || (i+1 < len(r) && unicode.IsDigit(r[i+1]))
+}
@@ -0,0 +1,123 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// MultiDB is a DB that reports a password as present if any
+// of its members has it.
+//
+// By default members are queried in order, and the lookup
+// stops at the first member that has the password, so cheap
+// databases, like a Bloom filter of the most common passwords,
+// should be placed first.
+//
+// If Parallel is set, all members are queried concurrently.
+// The first member that has the password wins, and the
+// lookups of the remaining members are cancelled, if they
+// implement DBContext.
+//
+// If a member returns an error, the remaining members are
+// still queried. If no member has the password, the errors
+// are returned joined, each as a *MemberError.
+type MultiDB struct {
+	Members  []DB
+	Parallel bool
+}
+
+// MemberError is returned by MultiDB when a member fails.
+type MemberError struct {
+	Index int // Index of the member in Members.
+	Err   error
+}
+
+// Error returns the error including the member index.
+func (e *MemberError) Error() string {
+	return fmt.Sprintf("member %d: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the error of the member.
+func (e *MemberError) Unwrap() error {
+	return e.Err
+}
+
+// NewMultiDB returns a DB that queries the members in order.
+func NewMultiDB(members ...DB) *MultiDB {
+	return &MultiDB{Members: members}
+}
+
+// Has satisfies the DB interface.
+func (m *MultiDB) Has(s string) (bool, error) {
+	return m.HasContext(context.Background(), s)
+}
+
+// HasContext satisfies the DBContext interface.
+func (m *MultiDB) HasContext(ctx context.Context, s string) (bool, error) {
+	i, err := m.Match(ctx, s)
+	return i >= 0, err
+}
+
+// Match returns the index of the member that has the password.
+// If no member has it, -1 is returned.
+// If a member has the password, errors from other members
+// are not returned.
+func (m *MultiDB) Match(ctx context.Context, s string) (int, error) {
+	if m.Parallel {
+		return m.matchParallel(ctx, s)
+	}
+	var errs []error
+	for i, db := range m.Members {
+		if err := ctx.Err(); err != nil {
+			return -1, err
+		}
+		found, err := has(ctx, db, s)
+		if err != nil {
+			errs = append(errs, &MemberError{Index: i, Err: err})
+			continue
+		}
+		if found {
+			return i, nil
+		}
+	}
+	return -1, errors.Join(errs...)
+}
+
+// matchParallel queries all members concurrently.
+func (m *MultiDB) matchParallel(ctx context.Context, s string) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		index int
+		found bool
+		err   error
+	}
+	// Buffered, so members finishing after a hit do not block.
+	results := make(chan result, len(m.Members))
+	for i, db := range m.Members {
+		go func(i int, db DB) {
+			found, err := has(ctx, db, s)
+			results <- result{index: i, found: found, err: err}
+		}(i, db)
+	}
+	errs := make([]error, len(m.Members))
+	for range m.Members {
+		r := <-results
+		if r.err != nil {
+			errs[r.index] = &MemberError{Index: r.index, Err: r.err}
+			continue
+		}
+		if r.found {
+			return r.index, nil
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return -1, err
+	}
+	// errors.Join discards nil errors.
+	return -1, errors.Join(errs...)
+}
@@ -0,0 +1,95 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/klauspost/password/drivers/testdb"
+)
+
+// blockDB is a database that blocks until the context is cancelled.
+type blockDB struct{}
+
+func (blockDB) Has(s string) (bool, error) {
+	panic("Has called on blockDB")
+}
+
+func (blockDB) HasContext(ctx context.Context, s string) (bool, error) {
+	<-ctx.Done()
+	return false, ctx.Err()
+}
+
+func TestMultiDB(t *testing.T) {
+	top := testdb.NewMemDB()
+	full := testdb.NewMemDB()
+	top.Add("password1")
+	full.Add("password1")
+	full.Add("qx7ztk9wvb3m")
+
+	for _, parallel := range []bool{false, true} {
+		db := &MultiDB{Members: []DB{top, errDB{}, full}, Parallel: parallel}
+		i, err := db.Match(context.Background(), "qx7ztk9wvb3m")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i != 2 {
+			t.Fatal("expected member 2 to match, got", i)
+		}
+		if err := Check("password1", db, nil); !errors.Is(err, ErrPasswordInDB) {
+			t.Fatal("expected ErrPasswordInDB, got", err)
+		}
+		if !parallel {
+			i, _ = db.Match(context.Background(), "password1")
+			if i != 0 {
+				t.Fatal("expected member 0 to match, got", i)
+			}
+		}
+
+		// Errors are returned when no member has the password.
+		db.Members = append(db.Members, errDB{})
+		found, err := db.Has("notindb12")
+		if found {
+			t.Fatal("notindb12 reported as found")
+		}
+		if !errors.Is(err, errLookup) {
+			t.Fatal("expected errLookup, got", err)
+		}
+		var merr *MemberError
+		if !errors.As(err, &merr) || merr.Index != 1 {
+			t.Fatal("expected MemberError for member 1, got", err)
+		}
+		if err.Error() != "member 1: lookup failed\nmember 3: lookup failed" {
+			t.Fatalf("unexpected error text %q", err.Error())
+		}
+	}
+}
+
+func TestMultiDBParallel(t *testing.T) {
+	full := testdb.NewMemDB()
+	full.Add("password1")
+
+	// A positive hit must cancel the blocked member.
+	db := &MultiDB{Members: []DB{blockDB{}, full}, Parallel: true}
+	i, err := db.Match(context.Background(), "password1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i != 1 {
+		t.Fatal("expected member 1 to match, got", i)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = db.HasContext(ctx, "notindb12")
+	if err != context.Canceled {
+		t.Fatal("expected context.Canceled, got", err)
+	}
+	db.Parallel = false
+	_, err = db.HasContext(ctx, "notindb12")
+	if err != context.Canceled {
+		t.Fatal("expected context.Canceled, got", err)
+	}
+}
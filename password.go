@@ -17,15 +17,10 @@
 package password
 
 import (
+	"context"
 	"errors"
-	"io"
 	"log"
 	"os"
-	"strings"
-	"time"
-	"unicode/utf8"
-
-	"golang.org/x/text/unicode/norm"
 )
 
 // Logger used for output during Import.
@@ -39,12 +34,27 @@ type DbWriter interface {
 	Add(string) error
 }
 
+// A DbWriterContext is a DbWriter that can abort an Add
+// when the supplied context is cancelled.
+// If the writer implements this, ImportContext will use it.
+type DbWriterContext interface {
+	AddContext(context.Context, string) error
+}
+
 // A DB should check the database for the supplied password.
 // The password sent to the interface has always been sanitized.
 type DB interface {
 	Has(string) (bool, error)
 }
 
+// A DBContext is a DB that can abort a lookup when the
+// supplied context is cancelled.
+// If the database implements this, CheckContext will use it.
+// Databases that are entirely in memory do not need to implement this.
+type DBContext interface {
+	HasContext(context.Context, string) (bool, error)
+}
+
 // A Sanitizer should prepare a password, and check
 // the basic properties that should be satisfied.
 // For an example, see DefaultSanitizer
@@ -74,16 +84,23 @@ type Tokenizer interface {
 //  - Normalize input using Unicode Normalization Form KD
 //
 // If input is less than 8 runes ErrSanitizeTooShort is returned.
+// Errors are returned as *CheckError with the sentinel errors wrapped.
+//
+// DefaultSanitizer is created by NewSanitizer(DefaultSanitizerOptions()).
 var DefaultSanitizer Sanitizer
 
 func init() {
-	DefaultSanitizer = &defaultSanitizer{}
+	DefaultSanitizer = NewSanitizer(DefaultSanitizerOptions())
 }
 
 // ErrSanitizeTooShort is returned by the default sanitizer,
 // if the input password is less than 8 runes.
 var ErrSanitizeTooShort = errors.New("password too short")
 
+// ErrSanitizeTooLong is returned by sanitizers created by NewSanitizer
+// if the password is longer than the configured maximum.
+var ErrSanitizeTooLong = errors.New("password too long")
+
 // ErrInvalidString is returned by the default sanitizer
 // if the string contains an invalid utf8 character sequence.
 var ErrInvalidString = errors.New("invalid utf8 sequence")
@@ -92,134 +109,75 @@ var ErrInvalidString = errors.New("invalid utf8 sequence")
 // database.
 var ErrPasswordInDB = errors.New("password found in database")
 
-// doc at DefaultSanitizer
-type defaultSanitizer struct{}
-
-// doc at DefaultSanitizer
-func (d defaultSanitizer) Sanitize(in string) (string, error) {
-	in = strings.TrimSpace(in)
-	if utf8.RuneCountInString(in) < 8 {
-		return "", ErrSanitizeTooShort
-	}
-	if !utf8.ValidString(in) {
-		return "", ErrInvalidString
-	}
-	in = norm.NFKD.String(in)
-	in = strings.TrimSpace(in)
-	return in, nil
+// Check a password against the database.
+// It will return an error if:
+//  - Sanitazition fails.
+//  - DB lookup returns an error
+//  - Password is in database (ErrPasswordInDB)
+// If nil is passed as Sanitizer, DefaultSanitizer will be used.
+// To fold homoglyphs or use another Folding, use CheckWithOptions.
+//
+// Rejected passwords will return a *CheckError, so use
+// errors.Is(err, ErrPasswordInDB) to check for a specific error.
+func Check(password string, db DB, san Sanitizer) error {
+	return CheckContext(context.Background(), password, db, san)
 }
 
-type initer interface {
-	Init() error
+// CheckContext is the same as Check, but the lookup can be
+// cancelled using the supplied context.
+// If the DB implements DBContext the context is forwarded to it,
+// otherwise the context is only checked before the lookup.
+func CheckContext(ctx context.Context, password string, db DB, san Sanitizer) error {
+	return CheckWithOptionsContext(ctx, password, db, CheckOptions{Sanitizer: san})
 }
 
-// Import will populate a database with common passwords.
+// CheckMany will check several passwords against the database.
 //
-// You must supply a Tokenizer (see tokenizer package for default tokenizers)
-// that will deliver the passwords,
-// a DbWriter, where the passwords will be sent,
-// and finally a Sanitizer to clean up the passwords -
-// - if you send nil DefaultSanitizer will be used.
-func Import(in Tokenizer, out DbWriter, san Sanitizer) (err error) {
-	bulk, ok := out.(BulkWriter)
-	if ok {
-		initer, ok := out.(initer)
-		if ok {
-			err := initer.Init()
-			if err != nil {
-				return err
-			}
-		}
-		closer, ok := out.(io.Closer)
-		if ok {
-			defer func() {
-				e := closer.Close()
-				if e != nil && err == nil {
-					err = e
-				}
-			}()
-		}
-		out = bulkWrap(bulk)
-	}
+// An error is returned for each password, with the same meaning as the
+// error returned by Check. The index of the error matches the index
+// of the password.
+//
+// If the DB implements BulkDB, all passwords that pass the sanitizer are
+// looked up at once, otherwise they are looked up one by one.
+// If the lookup fails, the error is returned for all passwords that
+// passed the sanitizer.
+// If nil is passed as Sanitizer, DefaultSanitizer will be used.
+// To fold homoglyphs or use another Folding, use CheckManyWithOptions.
+func CheckMany(passwords []string, db DB, san Sanitizer) []error {
+	return CheckManyContext(context.Background(), passwords, db, san)
+}
 
-	initer, ok := out.(initer)
-	if ok {
-		err := initer.Init()
-		if err != nil {
-			return err
-		}
-	}
+// CheckManyContext is the same as CheckMany, but the lookup can be
+// cancelled using the supplied context.
+func CheckManyContext(ctx context.Context, passwords []string, db DB, san Sanitizer) []error {
+	return CheckManyWithOptionsContext(ctx, passwords, db, CheckOptions{Sanitizer: san})
+}
 
-	closer, ok := out.(io.Closer)
-	if ok {
-		defer func() {
-			e := closer.Close()
-			if e != nil && err == nil {
-				err = e
-			}
-		}()
-	}
+// inDBError returns the error for a password found in the database.
+func inDBError(normalized string) *CheckError {
+	return &CheckError{Reason: ReasonInDB, Normalized: normalized, Err: ErrPasswordInDB}
+}
 
-	if san == nil {
-		san = DefaultSanitizer
+// has will look up s in db, using HasContext if db supports it.
+func has(ctx context.Context, db DB, s string) (bool, error) {
+	if dbc, ok := db.(DBContext); ok {
+		return dbc.HasContext(ctx, s)
 	}
-
-	start := time.Now()
-	i := 0
-	added := 0
-	for {
-		record, err := in.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return err
-		}
-
-		valstring, err := san.Sanitize(record)
-		if err == nil {
-			valstring = strings.ToLower(valstring)
-			err = out.Add(valstring)
-			if err != nil {
-				return err
-			}
-			added++
-		}
-		i++
-		if i%10000 == 0 {
-			elapsed := time.Since(start)
-			Logger.Printf("Read %d, (%0.0f per sec). Added: %d (%d%%)\n", i, float64(i)/elapsed.Seconds(), added, (added*100)/i)
-		}
+	if err := ctx.Err(); err != nil {
+		return false, err
 	}
-	elapsed := time.Since(start)
-	Logger.Printf("Processing took %s, processing %d entries.\n", elapsed, i)
-	Logger.Printf("%0.2f entries/sec.", float64(i)/elapsed.Seconds())
-	return nil
+	return db.Has(s)
 }
 
-// Check a password against the database.
-// It will return an error if:
-//  - Sanitazition fails.
-//  - DB lookup returns an error
-//  - Password is in database (ErrPasswordInDB)
-// If nil is passed as Sanitizer, DefaultSanitizer will be used.
-func Check(password string, db DB, san Sanitizer) error {
-	if san == nil {
-		san = DefaultSanitizer
+// add will add s to out, using AddContext if out supports it.
+func add(ctx context.Context, out DbWriter, s string) error {
+	if w, ok := out.(DbWriterContext); ok {
+		return w.AddContext(ctx, s)
 	}
-	p, err := san.Sanitize(password)
-	if err != nil {
+	if err := ctx.Err(); err != nil {
 		return err
 	}
-	p = strings.ToLower(p)
-	has, err := db.Has(p)
-	if err != nil {
-		return err
-	}
-	if has {
-		return ErrPasswordInDB
-	}
-	return nil
+	return out.Add(s)
 }
 
 // Sanitize will sanitize a password, useful before hashing
@@ -4,6 +4,7 @@ package password
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"os"
@@ -118,6 +119,87 @@ func TestImportBulk(t *testing.T) {
 	}
 }
 
+// repeatTokenizer returns the same password forever.
+type repeatTokenizer string
+
+func (r repeatTokenizer) Next() (string, error) {
+	return string(r), nil
+}
+
+// endlessTokenizer returns a new password forever.
+type endlessTokenizer int
+
+func (e *endlessTokenizer) Next() (string, error) {
+	*e++
+	return fmt.Sprintf("password%d", *e), nil
+}
+
+// cancelWriter is a bulk writer that cancels the context
+// after a number of batches.
+type cancelWriter struct {
+	batches int
+	cancel  context.CancelFunc
+}
+
+func (c *cancelWriter) Add(s string) error {
+	return nil
+}
+
+func (c *cancelWriter) AddMultiple(s []string) error {
+	c.batches--
+	if c.batches == 0 {
+		c.cancel()
+	}
+	return nil
+}
+
+func TestImportContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	out := &cancelWriter{batches: 3, cancel: cancel}
+	err := ImportContext(ctx, repeatTokenizer("password1"), out, nil)
+	if err != context.Canceled {
+		t.Fatal("expected context.Canceled, got", err)
+	}
+	if out.batches > 0 {
+		t.Fatal("import stopped before context was cancelled")
+	}
+}
+
+// errWriter is a bulk writer that always returns an error.
+type errWriter struct{}
+
+var errWrite = errors.New("write failed")
+
+func (e errWriter) Add(s string) error {
+	return errWrite
+}
+
+func (e errWriter) AddMultiple(s []string) error {
+	return errWrite
+}
+
+func TestImportWriterError(t *testing.T) {
+	err := Import(repeatTokenizer("password1"), errWriter{}, nil)
+	if err != errWrite {
+		t.Fatal("expected write error, got", err)
+	}
+}
+
+func TestCheckContext(t *testing.T) {
+	mem := testdb.NewMemDB()
+	mem.Add("password1")
+	err := CheckContext(context.Background(), "PASSWORD1", mem, nil)
+	if !errors.Is(err, ErrPasswordInDB) {
+		t.Fatal("expected ErrPasswordInDB, got", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = CheckContext(ctx, "PASSWORD1", mem, nil)
+	if err != context.Canceled {
+		t.Fatal("expected context.Canceled, got", err)
+	}
+}
+
 func TestInDB(t *testing.T) {
 	buf, err := testdata.Asset("testdata.txt.gz")
 	if err != nil {
@@ -141,7 +223,7 @@ func TestInDB(t *testing.T) {
 			t.Fatalf("db should have: %s", p)
 		}
 		err = Check(p, mem, nil)
-		if err != ErrPasswordInDB {
+		if !errors.Is(err, ErrPasswordInDB) {
 			t.Fatal("check failed on:", p, err)
 		}
 	}
@@ -186,7 +268,7 @@ func TestInDBBulk(t *testing.T) {
 			t.Fatalf("db should have: %s", p)
 		}
 		err = Check(p, mem, nil)
-		if err != ErrPasswordInDB {
+		if !errors.Is(err, ErrPasswordInDB) {
 			t.Fatal("check failed on:", p, err)
 		}
 	}
@@ -324,3 +406,65 @@ func ExampleImport() {
 	fmt.Println(err)
 	// Output:password found in database
 }
+
+func TestCheckMany(t *testing.T) {
+	for _, db := range []DB{testdb.NewMemDB(), testdb.NewMemDBBulk()} {
+		db.(DbWriter).Add("password1")
+		db.(DbWriter).Add("password2")
+		errs := CheckMany([]string{"password1", "short", "notindatabase", "PASSWORD2", "password1"}, db, nil)
+		want := []error{ErrPasswordInDB, ErrSanitizeTooShort, nil, ErrPasswordInDB, ErrPasswordInDB}
+		for i := range want {
+			if !errors.Is(errs[i], want[i]) {
+				t.Fatalf("%T: entry %d, expected %v, got %v", db, i, want[i], errs[i])
+			}
+		}
+	}
+
+	// Lookup errors are returned for all sanitized entries.
+	errs := CheckMany([]string{"password1", "short"}, errDB{}, nil)
+	if errs[0] != errLookup || !errors.Is(errs[1], ErrSanitizeTooShort) {
+		t.Fatal("unexpected errors:", errs)
+	}
+}
+
+// errDB is a database that always returns an error.
+type errDB struct{}
+
+var errLookup = errors.New("lookup failed")
+
+func (e errDB) Has(s string) (bool, error) {
+	return false, errLookup
+}
+
+func TestCheckError(t *testing.T) {
+	mem := testdb.NewMemDB()
+	mem.Add("password1")
+	err := Check("PassWord1", mem, nil)
+	var ce *CheckError
+	if !errors.As(err, &ce) {
+		t.Fatalf("expected *CheckError, got %T", err)
+	}
+	if ce.Reason != ReasonInDB || ce.Normalized != "password1" {
+		t.Fatalf("unexpected error content: %+v", ce)
+	}
+	if err.Error() != ErrPasswordInDB.Error() {
+		t.Fatal("unexpected error message:", err)
+	}
+
+	err = Check("shørt", mem, nil)
+	if !errors.Is(err, ErrSanitizeTooShort) || ReasonOf(err) != ReasonTooShort {
+		t.Fatal("expected too short, got", err)
+	}
+	errors.As(err, &ce)
+	if ce.Min != 8 || ce.Actual != 5 {
+		t.Fatalf("unexpected lengths: %+v", ce)
+	}
+
+	err = Check("invalid\xffstring", mem, nil)
+	if !errors.Is(err, ErrInvalidString) || ReasonOf(err) != ReasonInvalid {
+		t.Fatal("expected invalid string, got", err)
+	}
+	if ReasonOf(errLookup) != "" {
+		t.Fatal("expected no reason")
+	}
+}
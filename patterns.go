@@ -0,0 +1,132 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// ErrPattern is returned by PatternValidator if too much of the
+// password consists of repeated or sequential characters.
+var ErrPattern = errors.New("password consists of repeated or sequential characters")
+
+// PatternValidator will reject passwords where a large part of the
+// password consists of simple patterns:
+//
+//   - Runs of the same character, like "aaaa".
+//   - Repeated substrings, like "abcabcabc" or "passpass".
+//   - Ascending or descending sequences of letters or digits,
+//     like "abcd", "4321" or "αβγδ".
+//
+// Patterns are detected case insensitively.
+// The validator returns the input unchanged, so it should be
+// used in a Chain after a sanitizer.
+// Rejected passwords will return a *CheckError with ReasonPattern.
+type PatternValidator struct {
+	// MaxCoverage is the maximum fraction (0 to 1) of the password
+	// that may be covered by patterns. If 0, 0.5 is used.
+	MaxCoverage float64
+
+	// MinRun is the minimum length of a pattern.
+	// If 0, 3 is used.
+	MinRun int
+}
+
+// Sanitize will reject the password if it is covered too much by patterns.
+func (p PatternValidator) Sanitize(s string) (string, error) {
+	maxCov := p.MaxCoverage
+	if maxCov == 0 {
+		maxCov = 0.5
+	}
+	minRun := p.MinRun
+	if minRun == 0 {
+		minRun = 3
+	}
+	if patternCoverage([]rune(strings.ToLower(s)), minRun) > maxCov {
+		return "", &CheckError{Reason: ReasonPattern, Err: ErrPattern}
+	}
+	return s, nil
+}
+
+// maxPatternRunes is the maximum number of runes checked for
+// repeated substrings, since that check is quadratic.
+const maxPatternRunes = 256
+
+// patternCoverage returns the fraction of r that is covered by
+// patterns at least minRun runes long.
+func patternCoverage(r []rune, minRun int) float64 {
+	if len(r) == 0 {
+		return 0
+	}
+	covered := make([]bool, len(r))
+	mark := func(from, to int) {
+		if to-from < minRun {
+			return
+		}
+		for i := from; i < to; i++ {
+			covered[i] = true
+		}
+	}
+
+	// Runs of the same rune.
+	start := 0
+	for i := 1; i <= len(r); i++ {
+		if i == len(r) || r[i] != r[start] {
+			mark(start, i)
+			start = i
+		}
+	}
+
+	// Ascending and descending sequences.
+	for _, dir := range []rune{1, -1} {
+		start = 0
+		for i := 1; i <= len(r); i++ {
+			if i == len(r) || r[i]-r[i-1] != dir || !isSequenceRune(r[i]) || !isSequenceRune(r[i-1]) {
+				mark(start, i)
+				start = i
+			}
+		}
+	}
+
+	// Repeated substrings with period p.
+	// Substrings shorter than 4 runes must be repeated at least
+	// twice, like "xyxyxy", since words like "mississippi" and
+	// "banana" contain short substrings repeated once.
+	// Longer substrings must be repeated once, like "passpass".
+	n := len(r)
+	if n > maxPatternRunes {
+		n = maxPatternRunes
+	}
+	for p := 2; p <= n/2; p++ {
+		need := p
+		if p < 4 {
+			need = 2 * p
+		}
+		matches := 0
+		for i := 0; i+p <= n; i++ {
+			if i+p < n && r[i] == r[i+p] {
+				matches++
+				continue
+			}
+			if matches >= need {
+				mark(i-matches, i+p)
+			}
+			matches = 0
+		}
+	}
+
+	n = 0
+	for _, c := range covered {
+		if c {
+			n++
+		}
+	}
+	return float64(n) / float64(len(r))
+}
+
+// isSequenceRune returns true if r can be part of a sequence.
+func isSequenceRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
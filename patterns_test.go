@@ -0,0 +1,80 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPatternValidator(t *testing.T) {
+	rejected := []string{
+		"aaaaaaaaaa",
+		"1234567890",
+		"abcdefgh",
+		"abcabcabc",
+		"ZYXWVUTS",
+		"passwordpassword",
+		"abc12345",
+		"αβγδεζηθ",
+		"٠١٢٣٤٥٦٧",
+		"xyxyxyxyxy",
+	}
+	v := PatternValidator{}
+	for _, pw := range rejected {
+		_, err := v.Sanitize(pw)
+		if !errors.Is(err, ErrPattern) || ReasonOf(err) != ReasonPattern {
+			t.Errorf("%q: expected ErrPattern, got %v", pw, err)
+		}
+	}
+	accepted := []string{
+		"Password123",
+		"correct horse battery staple",
+		"MyP/|$$W0rd",
+		"acegikmo",
+		"",
+		// Dictionary words with short repeated substrings.
+		"mississippi",
+		"Mississippi1",
+		"bananas1",
+		"coconut99",
+		"murmuring",
+		"tartare1",
+	}
+	for _, pw := range accepted {
+		out, err := v.Sanitize(pw)
+		if err != nil {
+			t.Errorf("%q: unexpected error %v", pw, err)
+		}
+		if out != pw {
+			t.Errorf("%q: input changed to %q", pw, out)
+		}
+	}
+
+	// Threshold is respected.
+	strict := PatternValidator{MaxCoverage: 0.2}
+	if _, err := strict.Sanitize("Password123"); !errors.Is(err, ErrPattern) {
+		t.Error("expected ErrPattern with strict threshold, got", err)
+	}
+}
+
+func TestPatternCoverage(t *testing.T) {
+	tests := []struct {
+		s   string
+		cov float64
+	}{
+		{"aaaa", 1},
+		{"aabb", 0},
+		{"xx123xx", 3.0 / 7},
+		{"1234567890", 0.9},
+		{"mississippi", 0},
+		{"ababab", 1},
+		{"ababa", 0},
+		{"passpass", 1},
+	}
+	for _, test := range tests {
+		if cov := patternCoverage([]rune(test.s), 3); cov != test.cov {
+			t.Errorf("%q: expected %v, got %v", test.s, test.cov, cov)
+		}
+	}
+}
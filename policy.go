@@ -0,0 +1,194 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Requirement identifies a requirement checked by a Policy.
+type Requirement string
+
+// Requirements checked by a Policy.
+const (
+	RequireMinLength     Requirement = "min_length"     // Password has at least MinRunes characters
+	RequireMaxLength     Requirement = "max_length"     // Password has at most MaxRunes characters
+	RequirePrintable     Requirement = "printable"      // Password only has printable characters and spaces
+	RequireNotInDB       Requirement = "not_in_db"      // Password is not in the dictionary
+	RequireNoUserContext Requirement = "no_context"     // Password is not based on user information
+	RequireNoComposition Requirement = "no_composition" // No composition rules are imposed
+)
+
+// Policy bundles the checks of a password policy.
+// Use NISTPolicy to get a policy following NIST SP 800-63B.
+// A Policy is safe for concurrent use if the DB and
+// sanitizers are.
+type Policy struct {
+	// MinRunes is the minimum number of characters.
+	// Characters are counted as Unicode code points before normalization,
+	// with leading and trailing spaces removed.
+	MinRunes int
+
+	// MaxRunes is the maximum number of characters, counted as MinRunes.
+	// 0 means no maximum.
+	MaxRunes int
+
+	// Sanitizer is used to normalize the password before it is checked.
+	// If nil, leading and trailing spaces are removed,
+	// and NFKD normalization is used, like DefaultSanitizer.
+	// The sanitizer should not reject passwords by itself,
+	// since the requirement will not be reported.
+	Sanitizer Sanitizer
+
+	// DB is the dictionary of breached and common passwords.
+	// If nil, the dictionary requirement is skipped.
+	DB DB
+
+	// CheckOptions are used for the dictionary lookup.
+	// The Sanitizer is ignored, since the password is already normalized.
+	// Folding and Confusables must match the options used to import DB.
+	CheckOptions CheckOptions
+}
+
+// NISTPolicy returns a policy that follows the memorized secret
+// requirements of NIST SP 800-63B section 5.1.1.2:
+//
+//   - Passwords must be at least 8 characters.
+//   - Passwords of at least 64 characters must be allowed.
+//     Passwords of up to 1024 characters are allowed, which only
+//     guards against oversized input.
+//   - All printable Unicode characters and spaces are allowed.
+//   - Passwords are normalized using NFKD before they are checked.
+//   - Passwords are checked against the supplied dictionary of
+//     breached and common passwords.
+//   - Passwords are checked against context-specific words,
+//     based on the UserInfo supplied to Evaluate.
+//   - No composition rules are imposed.
+//
+// The dictionary should be imported with the DefaultSanitizer,
+// which uses the same normalization.
+func NISTPolicy(db DB) *Policy {
+	return &Policy{
+		MinRunes: 8,
+		MaxRunes: 1024,
+		DB:       db,
+	}
+}
+
+// RequirementResult is the outcome of checking a single requirement.
+type RequirementResult struct {
+	Requirement Requirement
+	Description string // Human readable description of the requirement.
+	Skipped     bool   // The requirement was not checked.
+	Err         error  // The reason the requirement failed, nil if it passed.
+}
+
+// Passed returns whether the requirement was checked and passed.
+func (r RequirementResult) Passed() bool {
+	return !r.Skipped && r.Err == nil
+}
+
+// PolicyReport contains the result of evaluating a password
+// against a Policy. All requirements are reported,
+// also if one of them fails.
+type PolicyReport struct {
+	Results []RequirementResult
+}
+
+// OK returns whether no requirement failed.
+func (p *PolicyReport) OK() bool {
+	return p.Err() == nil
+}
+
+// Err returns the error of the first requirement that failed,
+// or nil if no requirement failed.
+func (p *PolicyReport) Err() error {
+	for _, r := range p.Results {
+		if r.Err != nil {
+			return r.Err
+		}
+	}
+	return nil
+}
+
+// Result returns the result of the requirement.
+// If the requirement is not in the report, false is returned.
+func (p *PolicyReport) Result(req Requirement) (RequirementResult, bool) {
+	for _, r := range p.Results {
+		if r.Requirement == req {
+			return r, true
+		}
+	}
+	return RequirementResult{}, false
+}
+
+// Evaluate will check a password against all requirements of the policy,
+// and return a report of each requirement and its outcome.
+// If user is empty, the context-specific word check is skipped.
+func (p *Policy) Evaluate(password string, user UserInfo) *PolicyReport {
+	return p.EvaluateContext(context.Background(), password, user)
+}
+
+// EvaluateContext is the same as Evaluate, but the dictionary lookup
+// can be cancelled using the supplied context.
+func (p *Policy) EvaluateContext(ctx context.Context, password string, user UserInfo) *PolicyReport {
+	rep := &PolicyReport{}
+	result := func(req Requirement, desc string, skip bool, err error) {
+		rep.Results = append(rep.Results, RequirementResult{Requirement: req, Description: desc, Skipped: skip, Err: err})
+	}
+
+	san := p.Sanitizer
+	if san == nil {
+		san = NewSanitizer(SanitizerOptions{Trim: true, Form: NormNFKD})
+	}
+	// Normalization may change the number of runes, so the length
+	// is checked on the input, as typed by the user.
+	raw := strings.TrimSpace(password)
+	validateRaw := func(v Sanitizer) error {
+		_, err := v.Sanitize(raw)
+		return err
+	}
+	result(RequireMinLength, fmt.Sprintf("Password must have at least %d characters", p.MinRunes), false, validateRaw(MinRunes(p.MinRunes)))
+	if p.MaxRunes > 0 {
+		result(RequireMaxLength, fmt.Sprintf("Passwords of up to %d characters are allowed", p.MaxRunes), false, validateRaw(MaxRunes(p.MaxRunes)))
+	} else {
+		result(RequireMaxLength, "Passwords of any length are allowed", false, nil)
+	}
+
+	pw, err := san.Sanitize(password)
+	if err != nil {
+		// The password cannot be checked further.
+		const skipped = "Skipped, since the password could not be normalized"
+		result(RequirePrintable, "Password must be valid and only contain printable characters", false, err)
+		result(RequireNotInDB, skipped, true, nil)
+		result(RequireNoUserContext, skipped, true, nil)
+		result(RequireNoComposition, "No composition rules are imposed", false, nil)
+		return rep
+	}
+	validate := func(v Sanitizer) error {
+		_, err := v.Sanitize(pw)
+		return err
+	}
+	unchanged := SanitizerFunc(func(s string) (string, error) { return s, nil })
+
+	result(RequirePrintable, "All printable characters and spaces are allowed", false, validate(RejectInvisible()))
+
+	if p.DB != nil {
+		opts := p.CheckOptions
+		opts.Sanitizer = unchanged
+		result(RequireNotInDB, "Password must not be in the dictionary of breached and common passwords", false, CheckWithOptionsContext(ctx, pw, p.DB, opts))
+	} else {
+		result(RequireNotInDB, "No dictionary configured", true, nil)
+	}
+
+	if user.Username != "" || user.Email != "" || user.DisplayName != "" || len(user.SiteNames) > 0 {
+		result(RequireNoUserContext, "Password must not be based on user or site information", false, validate(NewContextSanitizer(user, unchanged)))
+	} else {
+		result(RequireNoUserContext, "No user information supplied", true, nil)
+	}
+
+	result(RequireNoComposition, "No composition rules are imposed", false, nil)
+	return rep
+}
@@ -0,0 +1,100 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/password/drivers/testdb"
+)
+
+func TestNISTPolicy(t *testing.T) {
+	db := testdb.NewMemDB()
+	err := db.Add("password1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := NISTPolicy(db)
+	user := UserInfo{Username: "johndoe73", Email: "john@example.com"}
+
+	tests := []struct {
+		in     string
+		user   UserInfo
+		failed []Requirement
+	}{
+		{in: "correct horse battery staple", user: user},
+		{in: "æøå 日本語 😀 !#", user: user},
+		{in: strings.Repeat("a", 64), user: user},
+		{in: strings.Repeat("correct horse battery staple ", 3), user: user},
+		{in: "short", user: user, failed: []Requirement{RequireMinLength}},
+		{in: strings.Repeat("a", 1025), user: user, failed: []Requirement{RequireMaxLength}},
+		{in: "Password1", user: user, failed: []Requirement{RequireNotInDB}},
+		{in: "johndoe73!!", user: user, failed: []Requirement{RequireNoUserContext}},
+		{in: "pass\u200dword", user: user, failed: []Requirement{RequirePrintable}},
+		{in: "family\U0001f468\u200d\U0001f469\u200d\U0001f467pass", user: user},
+		{in: "johndoe73!!"},
+		// Length is counted before normalization.
+		{in: "\u00e9\u00e9\u00e9\u00e9", user: user, failed: []Requirement{RequireMinLength}},
+		{in: strings.Repeat("\u00e9", 64), user: user},
+		// Surrounding spaces are removed, as by DefaultSanitizer.
+		{in: " password1 ", user: user, failed: []Requirement{RequireNotInDB}},
+		{in: "   short   ", user: user, failed: []Requirement{RequireMinLength}},
+	}
+	for _, test := range tests {
+		rep := p.Evaluate(test.in, test.user)
+		if len(rep.Results) != 6 {
+			t.Fatalf("%q: expected 6 results, got %d", test.in, len(rep.Results))
+		}
+		var failed []Requirement
+		for _, r := range rep.Results {
+			if r.Err != nil {
+				failed = append(failed, r.Requirement)
+			}
+			if r.Description == "" {
+				t.Errorf("%q: %s has no description", test.in, r.Requirement)
+			}
+		}
+		if fmt.Sprint(failed) != fmt.Sprint(test.failed) {
+			t.Errorf("%q: expected %v to fail, got %v", test.in, test.failed, failed)
+		}
+		if rep.OK() != (len(test.failed) == 0) {
+			t.Errorf("%q: unexpected OK: %v", test.in, rep.OK())
+		}
+	}
+
+	rep := p.Evaluate("Password1", UserInfo{})
+	if !errors.Is(rep.Err(), ErrPasswordInDB) {
+		t.Fatal("expected ErrPasswordInDB, got", rep.Err())
+	}
+	r, ok := rep.Result(RequireNoUserContext)
+	if !ok || !r.Skipped || r.Passed() {
+		t.Fatalf("expected user context to be skipped, got %+v", r)
+	}
+	r, ok = rep.Result(RequireNoComposition)
+	if !ok || !r.Passed() {
+		t.Fatalf("expected composition to pass, got %+v", r)
+	}
+
+	// Without a database the lookup is skipped.
+	rep = NISTPolicy(nil).Evaluate("Password1", user)
+	if r, _ := rep.Result(RequireNotInDB); !r.Skipped || !rep.OK() {
+		t.Fatalf("expected dictionary to be skipped, got %+v", rep.Results)
+	}
+
+	// Invalid input cannot be checked further.
+	rep = p.Evaluate("pass\xffword", user)
+	if len(rep.Results) != 6 || !errors.Is(rep.Err(), ErrInvalidString) {
+		t.Fatalf("unexpected report %+v", rep.Results)
+	}
+	if r, _ := rep.Result(RequirePrintable); !errors.Is(r.Err, ErrInvalidString) {
+		t.Fatalf("expected printable to fail, got %+v", r)
+	}
+	for _, req := range []Requirement{RequireNotInDB, RequireNoUserContext} {
+		if r, _ := rep.Result(req); !r.Skipped {
+			t.Fatalf("expected %s to be skipped, got %+v", req, r)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import "context"
+
+// A DbRemover is used for removing passwords from a database.
+// Removing a password that is not in the database
+// must not return an error.
+type DbRemover interface {
+	Remove(string) error
+}
+
+// A DbRemoverContext is a DbRemover that can abort a Remove
+// when the supplied context is cancelled.
+// If the remover implements this, RemoveContext will use it.
+type DbRemoverContext interface {
+	RemoveContext(context.Context, string) error
+}
+
+// If your DbRemover implements this, input will be sent
+// in batches instead of using Remove.
+type BulkRemover interface {
+	RemoveMultiple([]string) error
+}
+
+// A BulkRemoverContext is a BulkRemover that can abort
+// a batch when the supplied context is cancelled.
+type BulkRemoverContext interface {
+	RemoveMultipleContext(context.Context, []string) error
+}
+
+// Remove will remove passwords from a database.
+// It mirrors Import, so the passwords delivered by the Tokenizer
+// are sanitized and lowercased the same way as when they were imported.
+//
+// If nil is passed as Sanitizer, DefaultSanitizer will be used.
+func Remove(in Tokenizer, out DbRemover, san Sanitizer) error {
+	return RemoveContext(context.Background(), in, out, san)
+}
+
+// RemoveContext is the same as Remove, but the removal can be
+// cancelled using the supplied context.
+func RemoveContext(ctx context.Context, in Tokenizer, out DbRemover, san Sanitizer) error {
+	_, err := RemoveWithOptionsContext(ctx, in, out, ImportOptions{Sanitizer: san})
+	return err
+}
+
+// RemoveWithOptions will remove passwords from a database,
+// with the settings supplied in opts.
+// Use the options used for importing, so the passwords
+// are converted to the same values.
+//
+// The returned statistics are the same as for an import,
+// except that Removed is the number of entries sent to the remover,
+// and Added is 0.
+func RemoveWithOptions(in Tokenizer, out DbRemover, opts ImportOptions) (ImportStats, error) {
+	return RemoveWithOptionsContext(context.Background(), in, out, opts)
+}
+
+// RemoveWithOptionsContext is the same as RemoveWithOptions,
+// but the removal can be cancelled using the supplied context.
+func RemoveWithOptionsContext(ctx context.Context, in Tokenizer, out DbRemover, opts ImportOptions) (ImportStats, error) {
+	var w DbWriter = removeWriter{out}
+	if bulk, ok := out.(BulkRemover); ok {
+		w = bulkRemoveWriter{removeWriter: removeWriter{out}, bulk: bulk}
+	}
+	return runImport(ctx, in, w, opts, true)
+}
+
+// removeWriter makes a DbRemover look like a DbWriter,
+// so removals can use the import code.
+type removeWriter struct {
+	out DbRemover
+}
+
+func (r removeWriter) Add(s string) error {
+	return r.out.Remove(s)
+}
+
+func (r removeWriter) AddContext(ctx context.Context, s string) error {
+	if rc, ok := r.out.(DbRemoverContext); ok {
+		return rc.RemoveContext(ctx, s)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.out.Remove(s)
+}
+
+// bulkRemoveWriter makes a BulkRemover look like a BulkWriter.
+type bulkRemoveWriter struct {
+	removeWriter
+	bulk BulkRemover
+}
+
+func (b bulkRemoveWriter) AddMultiple(s []string) error {
+	return b.bulk.RemoveMultiple(s)
+}
+
+func (b bulkRemoveWriter) AddMultipleContext(ctx context.Context, s []string) error {
+	if rc, ok := b.bulk.(BulkRemoverContext); ok {
+		return rc.RemoveMultipleContext(ctx, s)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return b.bulk.RemoveMultiple(s)
+}
@@ -0,0 +1,119 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/password/drivers/testdb"
+)
+
+// bulkRemover records the batches removed.
+type bulkRemover struct {
+	*testdb.MemDB
+	batches [][]string
+}
+
+func (b *bulkRemover) RemoveMultiple(s []string) error {
+	b.batches = append(b.batches, s)
+	for _, v := range s {
+		err := b.Remove(v)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestRemove(t *testing.T) {
+	db := testdb.NewMemDB()
+	in := sliceTokenizer{"password1", "password2", "password3", "Straßenbahn"}
+	_, err := ImportWithOptions(&in, db, ImportOptions{Folding: FoldUnicode})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in = sliceTokenizer{"PASSWORD1", "short", "password3"}
+	err = Remove(&in, db, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*db) != 2 {
+		t.Fatal("expected 2 entries, got", *db)
+	}
+	if _, ok := (*db)["password2"]; !ok {
+		t.Fatal("password2 was removed")
+	}
+
+	// Options must match the import.
+	b := &bulkRemover{MemDB: db}
+	in = sliceTokenizer{"STRASSENBAHN", "password2"}
+	stats, err := RemoveWithOptions(&in, b, ImportOptions{Folding: FoldUnicode, BatchSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*db) != 0 {
+		t.Fatal("expected empty database, got", *db)
+	}
+	if stats.Removed != 2 || stats.Added != 0 || len(b.batches) != 1 {
+		t.Fatalf("expected a single batch, got %v, stats %+v", b.batches, stats)
+	}
+}
+
+// ctxRemover records the contexts it is called with.
+type ctxRemover struct {
+	*bulkRemover
+	ctxs []context.Context
+}
+
+func (c *ctxRemover) RemoveContext(ctx context.Context, s string) error {
+	c.ctxs = append(c.ctxs, ctx)
+	return c.Remove(s)
+}
+
+func (c *ctxRemover) RemoveMultipleContext(ctx context.Context, s []string) error {
+	c.ctxs = append(c.ctxs, ctx)
+	return c.RemoveMultiple(s)
+}
+
+func TestRemoveContext(t *testing.T) {
+	db := testdb.NewMemDB()
+	in := sliceTokenizer{"password1", "password2", "password3"}
+	if err := Import(&in, db, nil); err != nil {
+		t.Fatal(err)
+	}
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "remove")
+	var logged bytes.Buffer
+	r := &ctxRemover{bulkRemover: &bulkRemover{MemDB: db}}
+	in = sliceTokenizer{"password1", "password2", "short"}
+	stats, err := RemoveWithOptionsContext(ctx, &in, r, ImportOptions{BatchSize: 10, Logger: log.New(&logged, "", 0), ProgressEvery: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Removed != 2 || stats.Added != 0 || len(*db) != 1 {
+		t.Fatalf("unexpected stats %+v, database %v", stats, *db)
+	}
+	if len(r.ctxs) != 1 || r.ctxs[0].Value(key{}) != "remove" {
+		t.Fatal("context not passed to RemoveMultipleContext", r.ctxs)
+	}
+	if !strings.Contains(logged.String(), "Removed: 2") || strings.Contains(logged.String(), "Added") {
+		t.Fatal("unexpected log output:", logged.String())
+	}
+
+	// A cancelled context is returned.
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	in = sliceTokenizer{"password3"}
+	if err := RemoveContext(cctx, &in, r, nil); !errors.Is(err, context.Canceled) {
+		t.Fatal("expected context.Canceled, got", err)
+	}
+	if len(*db) != 1 {
+		t.Fatal("expected password3 to be kept, got", *db)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// SanitizerOptions contains the settings for a sanitizer
+// created by NewSanitizer.
+type SanitizerOptions struct {
+	// MinRunes is the minimum number of runes in a password.
+	// 0 means no minimum.
+	MinRunes int
+
+	// MaxRunes is the maximum number of runes in a password.
+	// 0 means no maximum.
+	//
+	// Both MinRunes and MaxRunes are checked on the password as
+	// it was entered (after trimming), before normalization.
+	// Normalization may change the number of runes, but the user
+	// only sees the password that was typed.
+	//
+	// Do not set this to fit a password hash. Argon2id and
+	// Bcrypt with PreHash can hash passwords of any length.
+	MaxRunes int
+
+	// Form is the Unicode normalization applied to the password.
+	// The zero value is NFKD. Use NormNone to disable normalization.
+	Form Normalization
+
+	// Trim will remove whitespace from the start and end of the password,
+	// both before and after normalization.
+	Trim bool
+
+	// CaseFold will convert the password to lower case.
+	// This will make the passwords case insensitive when hashed.
+	CaseFold bool
+}
+
+// DefaultSanitizerOptions returns the options used for DefaultSanitizer.
+func DefaultSanitizerOptions() SanitizerOptions {
+	return SanitizerOptions{
+		MinRunes: 8,
+		Form:     NormNFKD,
+		Trim:     true,
+	}
+}
+
+// Normalization is a Unicode normalization applied by a sanitizer.
+type Normalization int
+
+const (
+	// NormNFKD is Unicode Normalization Form KD. This is the default.
+	NormNFKD Normalization = iota
+	// NormNFKC is Unicode Normalization Form KC.
+	NormNFKC
+	// NormNFC is Unicode Normalization Form C.
+	NormNFC
+	// NormNFD is Unicode Normalization Form D.
+	NormNFD
+	// NormNone will leave the password as it is.
+	NormNone
+)
+
+// String returns s normalized with n.
+func (n Normalization) String(s string) string {
+	switch n {
+	case NormNFKD:
+		return norm.NFKD.String(s)
+	case NormNFKC:
+		return norm.NFKC.String(s)
+	case NormNFC:
+		return norm.NFC.String(s)
+	case NormNFD:
+		return norm.NFD.String(s)
+	}
+	return s
+}
+
+// StandardSanitizer is a Sanitizer with settings specified
+// by SanitizerOptions. Use NewSanitizer to create one.
+// It is safe for concurrent use.
+type StandardSanitizer struct {
+	opts SanitizerOptions
+}
+
+// NewSanitizer returns a new sanitizer with the supplied options.
+//
+// The sanitizer performs the following:
+//
+//   - If Trim is set, trim space, tab and newlines from start+end of input
+//   - Check that there is at least MinRunes runes. Return ErrSanitizeTooShort if not.
+//   - Check that there is at most MaxRunes runes. Return ErrSanitizeTooLong if not.
+//   - Check that the input is valid utf8. Return ErrInvalidString if not.
+//   - Normalize input using the specified Form. Trim again if Trim is set.
+//   - If CaseFold is set, convert to lower case.
+//
+// Errors are returned as *CheckError with the sentinel errors wrapped.
+func NewSanitizer(opts SanitizerOptions) *StandardSanitizer {
+	return &StandardSanitizer{opts: opts}
+}
+
+// Options returns the options of the sanitizer.
+func (s *StandardSanitizer) Options() SanitizerOptions {
+	return s.opts
+}
+
+// Sanitize will sanitize the password as described in NewSanitizer.
+func (s *StandardSanitizer) Sanitize(in string) (string, error) {
+	o := s.opts
+	if o.Trim {
+		in = strings.TrimSpace(in)
+	}
+	n := utf8.RuneCountInString(in)
+	if n < o.MinRunes {
+		return "", &CheckError{Reason: ReasonTooShort, Min: o.MinRunes, Actual: n, Err: ErrSanitizeTooShort}
+	}
+	if o.MaxRunes > 0 && n > o.MaxRunes {
+		return "", &CheckError{Reason: ReasonTooLong, Max: o.MaxRunes, Actual: n, Err: ErrSanitizeTooLong}
+	}
+	if !utf8.ValidString(in) {
+		return "", &CheckError{Reason: ReasonInvalid, Err: ErrInvalidString}
+	}
+	in = o.Form.String(in)
+	if o.Trim {
+		in = strings.TrimSpace(in)
+	}
+	if o.CaseFold {
+		in = strings.ToLower(in)
+	}
+	return in, nil
+}
@@ -0,0 +1,54 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewSanitizer(t *testing.T) {
+	tests := []struct {
+		opts SanitizerOptions
+		in   string
+		out  string
+		err  error
+	}{
+		{opts: DefaultSanitizerOptions(), in: "  password  ", out: "password"},
+		{opts: DefaultSanitizerOptions(), in: " passwor ", err: ErrSanitizeTooShort},
+		{opts: DefaultSanitizerOptions(), in: "ﬁﬁﬁﬁﬁﬁﬁﬁ", out: "fifififififififi"},
+		{opts: SanitizerOptions{MinRunes: 12}, in: "password1234", out: "password1234"},
+		{opts: SanitizerOptions{MinRunes: 12}, in: "password123", err: ErrSanitizeTooShort},
+		{opts: SanitizerOptions{MinRunes: 12}, in: "ﬁnancial2024", out: "financial2024"},
+		{opts: SanitizerOptions{MinRunes: 12}, in: "ﬁnancial202", err: ErrSanitizeTooShort},
+		{opts: SanitizerOptions{MaxRunes: 10}, in: "ﬁﬁﬁﬁﬁﬁ", out: "fifififififi"},
+		{opts: SanitizerOptions{MaxRunes: 10}, in: "ﬁﬁﬁﬁﬁﬁﬁﬁﬁﬁﬁ", err: ErrSanitizeTooLong},
+		{opts: SanitizerOptions{Form: NormNone}, in: "ﬁﬁﬁﬁﬁﬁ", out: "ﬁﬁﬁﬁﬁﬁ"},
+		{opts: SanitizerOptions{Form: NormNFC}, in: "e\u0301", out: "\u00e9"},
+		{opts: SanitizerOptions{CaseFold: true}, in: " PassWord ", out: " password "},
+		{opts: SanitizerOptions{}, in: "\xff", err: ErrInvalidString},
+	}
+	for i, test := range tests {
+		san := NewSanitizer(test.opts)
+		if san.Options() != test.opts {
+			t.Fatalf("test %d: options not returned", i)
+		}
+		out, err := san.Sanitize(test.in)
+		if !errors.Is(err, test.err) {
+			t.Fatalf("test %d: expected error %v, got %v", i, test.err, err)
+		}
+		if out != test.out {
+			t.Fatalf("test %d: expected %q, got %q", i, test.out, out)
+		}
+	}
+
+	var ce *CheckError
+	_, err := NewSanitizer(SanitizerOptions{MaxRunes: 4}).Sanitize("password")
+	if !errors.As(err, &ce) || ce.Reason != ReasonTooLong || ce.Max != 4 || ce.Actual != 8 {
+		t.Fatalf("unexpected error: %#v", err)
+	}
+
+	if DefaultSanitizer.(*StandardSanitizer).Options() != DefaultSanitizerOptions() {
+		t.Fatal("DefaultSanitizer does not use DefaultSanitizerOptions")
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// ErrUserContext is returned by ContextSanitizer if the password
+// is too similar to information about the user or site.
+var ErrUserContext = errors.New("password is too similar to user information")
+
+// UserInfo contains information about a user and the site,
+// that should not be used in a password.
+type UserInfo struct {
+	Username    string
+	Email       string
+	DisplayName string
+	SiteNames   []string // Names of the site, company or product.
+}
+
+// ContextSanitizer will reject passwords that are based on
+// information about the user or site.
+// This is the "context-specific words" requirement of NIST SP 800-63B.
+//
+// The password is first sanitized by the Base sanitizer.
+// The password and the words below are converted to lower case
+// and NFKD normalized. The password is then rejected if it contains,
+// contains the reverse of, or is within MaxDistance edits of,
+// any of these words:
+//
+//   - The username.
+//   - The email, the local part of the email and the domain
+//     with and without top level domain.
+//   - The display name, and each word in it.
+//   - Each site name, and each word in them.
+//
+// Words shorter than MinWordRunes are ignored. Words shorter than
+// 5 runes must not be next to other letters in the password, so a
+// domain "doe.com" will reject "doe2024!" but not "doesntmatter".
+// Rejected passwords will return a *CheckError with ReasonUserContext,
+// where Detail contains the name of the matching field.
+type ContextSanitizer struct {
+	User UserInfo
+
+	// Base sanitizer, applied before checking.
+	// If nil, DefaultSanitizer will be used.
+	Base Sanitizer
+
+	// MaxDistance is the maximum edit distance between the password
+	// and a word, for the password to be rejected.
+	// If 0, 2 is used. Use a negative value to disable the check.
+	MaxDistance int
+
+	// MinWordRunes is the minimum length of words checked.
+	// If 0, 3 is used.
+	MinWordRunes int
+}
+
+// NewContextSanitizer returns a sanitizer that will reject
+// passwords based on the supplied user information.
+// If nil is passed as base, DefaultSanitizer will be used.
+func NewContextSanitizer(user UserInfo, base Sanitizer) *ContextSanitizer {
+	return &ContextSanitizer{User: user, Base: base}
+}
+
+// contextWord is a word with the field it came from.
+type contextWord struct {
+	field string
+	word  string
+}
+
+// words returns the words to check, in lower case and NFKD normalized.
+func (c *ContextSanitizer) words() []contextWord {
+	minLen := c.MinWordRunes
+	if minLen == 0 {
+		minLen = 3
+	}
+	var res []contextWord
+	seen := make(map[string]struct{})
+	add := func(field, w string) {
+		w = strings.ToLower(strings.TrimSpace(w))
+		if len([]rune(w)) < minLen {
+			return
+		}
+		w = norm.NFKD.String(w)
+		if _, ok := seen[w]; ok {
+			return
+		}
+		seen[w] = struct{}{}
+		res = append(res, contextWord{field: field, word: w})
+	}
+	addWords := func(field, s string) {
+		add(field, s)
+		for _, w := range strings.FieldsFunc(s, isSeparator) {
+			add(field, w)
+		}
+	}
+
+	u := c.User
+	add("username", u.Username)
+	if u.Email != "" {
+		add("email", u.Email)
+		local, domain := u.Email, ""
+		if i := strings.LastIndex(u.Email, "@"); i >= 0 {
+			local, domain = u.Email[:i], u.Email[i+1:]
+		}
+		add("email", local)
+		add("email", domain)
+		if i := strings.LastIndex(domain, "."); i > 0 {
+			addWords("email", domain[:i])
+		}
+	}
+	addWords("name", u.DisplayName)
+	for _, s := range u.SiteNames {
+		addWords("site", s)
+	}
+	return res
+}
+
+// wholeWordRunes is the length of words that
+// can be matched inside other words of a password.
+const wholeWordRunes = 5
+
+// containsWord returns whether s contains w, and, if w is shorter than
+// wholeWordRunes, the match is not next to other letters in s.
+func containsWord(s, w string, wr []rune) bool {
+	if len(wr) >= wholeWordRunes {
+		return strings.Contains(s, w)
+	}
+	for i := 0; ; {
+		j := strings.Index(s[i:], w)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(w)
+		before, _ := utf8.DecodeLastRuneInString(s[:start])
+		after, _ := utf8.DecodeRuneInString(s[end:])
+		if !(start > 0 && unicode.IsLetter(before) && unicode.IsLetter(wr[0])) &&
+			!(end < len(s) && unicode.IsLetter(after) && unicode.IsLetter(wr[len(wr)-1])) {
+			return true
+		}
+		_, size := utf8.DecodeRuneInString(s[start:])
+		i = start + size
+	}
+}
+
+// isSeparator returns true for runes that separate words.
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// Sanitize will sanitize the password with the base sanitizer,
+// and check it against the user information.
+func (c *ContextSanitizer) Sanitize(in string) (string, error) {
+	base := c.Base
+	if base == nil {
+		base = DefaultSanitizer
+	}
+	s, err := base.Sanitize(in)
+	if err != nil {
+		return "", err
+	}
+	maxDist := c.MaxDistance
+	if maxDist == 0 {
+		maxDist = 2
+	}
+	// The base sanitizer may not normalize the same way as words.
+	p := norm.NFKD.String(strings.ToLower(s))
+	rev := reverse(p)
+	pr := []rune(p)
+	for _, w := range c.words() {
+		wr := []rune(w.word)
+		match := containsWord(p, w.word, wr) || containsWord(rev, w.word, wr)
+		if !match && maxDist > 0 {
+			// Only compute the distance if the lengths are close.
+			if d := len(pr) - len(wr); d <= maxDist && d >= -maxDist {
+				match = levenshtein(pr, wr) <= maxDist
+			}
+		}
+		if match {
+			return "", &CheckError{Reason: ReasonUserContext, Detail: w.field, Err: ErrUserContext}
+		}
+	}
+	return s, nil
+}
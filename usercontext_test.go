@@ -0,0 +1,140 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestContextSanitizer(t *testing.T) {
+	san := NewContextSanitizer(UserInfo{
+		Username:    "johndoe73",
+		Email:       "john@doe.com",
+		DisplayName: "Jonathan Doe",
+		SiteNames:   []string{"Acme Widgets"},
+	}, nil)
+	rejected := map[string]string{
+		"johndoe73!":      "username",
+		"JohnDoe73":       "username",
+		"37eodnhoj":       "username",
+		"john@doe.com":    "email",
+		"moc.eod@nhoj":    "email",
+		"johndoe7":        "username",
+		"my jonathan pwd": "name",
+		"acmewidgets":     "site",
+		"widgets4ever":    "site",
+	}
+	for pw, field := range rejected {
+		_, err := san.Sanitize(pw)
+		if !errors.Is(err, ErrUserContext) {
+			t.Fatalf("%q: expected ErrUserContext, got %v", pw, err)
+		}
+		var ce *CheckError
+		errors.As(err, &ce)
+		if ce.Detail != field || ce.Reason != ReasonUserContext {
+			t.Fatalf("%q: expected field %q, got %q", pw, field, ce.Detail)
+		}
+	}
+	for _, pw := range []string{"correct horse battery", "MyP/|$$W0rd"} {
+		if _, err := san.Sanitize(pw); err != nil {
+			t.Fatalf("%q: unexpected error %v", pw, err)
+		}
+	}
+
+	// Short words are only matched when not part of another word.
+	san = NewContextSanitizer(UserInfo{
+		Email:       "john@doe.com",
+		DisplayName: "John Doe",
+		SiteNames:   []string{"Corp"},
+	}, nil)
+	for _, pw := range []string{"doesntmatter", "corporate99", "anecdote2024", "99etaroproc", "johnnycash77"} {
+		if _, err := san.Sanitize(pw); err != nil {
+			t.Errorf("%q: unexpected error %v", pw, err)
+		}
+	}
+	for pw, field := range map[string]string{
+		"doe2024!!":      "email",
+		"Corp-2024":      "site",
+		"my-corp-pw":     "site",
+		"2024proc":       "site",
+		"john 1234 pass": "email",
+	} {
+		_, err := san.Sanitize(pw)
+		var ce *CheckError
+		if !errors.As(err, &ce) || ce.Detail != field {
+			t.Errorf("%q: expected field %q, got %v", pw, field, err)
+		}
+	}
+	san = NewContextSanitizer(UserInfo{
+		Username:    "johndoe73",
+		Email:       "john@doe.com",
+		DisplayName: "Jonathan Doe",
+		SiteNames:   []string{"Acme Widgets"},
+	}, nil)
+
+	// Base sanitizer errors are returned.
+	if _, err := san.Sanitize("short"); !errors.Is(err, ErrSanitizeTooShort) {
+		t.Fatal("expected ErrSanitizeTooShort, got", err)
+	}
+
+	// Accented words are normalized like the password.
+	san = NewContextSanitizer(UserInfo{DisplayName: "Jos\u00e9 M\u00fcller"}, nil)
+	for pw, field := range map[string]string{
+		"m\u00fcller2024!":      "name",
+		"M\u00dcLLER2024!":      "name",
+		"mu\u0308ller2024!":     "name",
+		"my jos\u00e9 password": "name",
+	} {
+		_, err := san.Sanitize(pw)
+		var ce *CheckError
+		if !errors.As(err, &ce) || ce.Detail != field {
+			t.Fatalf("%q: expected ErrUserContext for %q, got %v", pw, field, err)
+		}
+	}
+	san.Base = SanitizerFunc(func(s string) (string, error) { return s, nil })
+	if _, err := san.Sanitize("m\u00fcller2024!"); !errors.Is(err, ErrUserContext) {
+		t.Fatal("expected ErrUserContext, got", err)
+	}
+
+	// Edit distance can be disabled.
+	san = NewContextSanitizer(UserInfo{Username: "wintermute"}, nil)
+	if _, err := san.Sanitize("wintermuet"); !errors.Is(err, ErrUserContext) {
+		t.Fatal("expected ErrUserContext, got", err)
+	}
+	san.MaxDistance = -1
+	if _, err := san.Sanitize("wintermuet"); err != nil {
+		t.Fatal("unexpected error", err)
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		d    int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"winter2023!", "winter2024!", 1},
+		{"æøå", "åøæ", 2},
+	}
+	for _, test := range tests {
+		if d := levenshtein([]rune(test.a), []rune(test.b)); d != test.d {
+			t.Errorf("levenshtein(%q, %q): expected %d, got %d", test.a, test.b, test.d, d)
+		}
+	}
+}
+
+// This example shows how to reject passwords based on user information.
+func ExampleContextSanitizer() {
+	san := NewContextSanitizer(UserInfo{Username: "johndoe73", Email: "john@doe.com"}, nil)
+
+	fmt.Println(SanitizeOK("johndoe73!", san))
+	fmt.Println(SanitizeOK("moc.eod@nhoj", san))
+	fmt.Println(SanitizeOK("MyP/|$$W0rd", san))
+	// Output: password is too similar to user information
+	// password is too similar to user information
+	// <nil>
+}
@@ -0,0 +1,170 @@
+// Copyright 2015, Klaus Post, see LICENSE for details.
+
+package password
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The validators in this file return the input unchanged,
+// and are meant to be combined with a sanitizer using Chain.
+// All errors are returned as *CheckError.
+
+// ErrForbiddenRune is returned by ForbidRunes if the
+// password contains a forbidden character.
+var ErrForbiddenRune = errors.New("password contains a forbidden character")
+
+// ErrDenied is returned by DenyRegexp if the
+// password matches a denied pattern.
+var ErrDenied = errors.New("password matches a denied pattern")
+
+// ErrTooFewDistinct is returned by MinDistinctRunes if the
+// password has too few different characters.
+var ErrTooFewDistinct = errors.New("password has too few different characters")
+
+// ErrInvisible is returned by RejectInvisible if the
+// password contains an invisible or control character.
+var ErrInvisible = errors.New("password contains an invisible character")
+
+// MinRunes returns a validator that will reject passwords
+// with less than n runes with ErrSanitizeTooShort.
+func MinRunes(n int) Sanitizer {
+	return SanitizerFunc(func(s string) (string, error) {
+		if l := utf8.RuneCountInString(s); l < n {
+			return "", &CheckError{Reason: ReasonTooShort, Min: n, Actual: l, Err: ErrSanitizeTooShort}
+		}
+		return s, nil
+	})
+}
+
+// MaxRunes returns a validator that will reject passwords
+// with more than n runes with ErrSanitizeTooLong.
+func MaxRunes(n int) Sanitizer {
+	return SanitizerFunc(func(s string) (string, error) {
+		if l := utf8.RuneCountInString(s); l > n {
+			return "", &CheckError{Reason: ReasonTooLong, Max: n, Actual: l, Err: ErrSanitizeTooLong}
+		}
+		return s, nil
+	})
+}
+
+// ForbidRunes returns a validator that will reject passwords
+// containing any of the runes in chars with ErrForbiddenRune.
+// The forbidden rune is returned in the Detail of the error.
+func ForbidRunes(chars string) Sanitizer {
+	return SanitizerFunc(func(s string) (string, error) {
+		if i := strings.IndexAny(s, chars); i >= 0 {
+			r, _ := utf8.DecodeRuneInString(s[i:])
+			return "", &CheckError{Reason: ReasonForbiddenRune, Detail: string(r), Err: ErrForbiddenRune}
+		}
+		return s, nil
+	})
+}
+
+// DenyRegexp returns a validator that will reject passwords
+// matching any of the supplied regular expressions with ErrDenied.
+// The matching expression is returned in the Detail of the error.
+func DenyRegexp(res ...*regexp.Regexp) Sanitizer {
+	return SanitizerFunc(func(s string) (string, error) {
+		for _, re := range res {
+			if re.MatchString(s) {
+				return "", &CheckError{Reason: ReasonDenied, Detail: re.String(), Err: ErrDenied}
+			}
+		}
+		return s, nil
+	})
+}
+
+// MinDistinctRunes returns a validator that will reject passwords
+// with less than n different runes with ErrTooFewDistinct.
+func MinDistinctRunes(n int) Sanitizer {
+	return SanitizerFunc(func(s string) (string, error) {
+		seen := make(map[rune]struct{}, n)
+		for _, r := range s {
+			if len(seen) >= n {
+				break
+			}
+			seen[r] = struct{}{}
+		}
+		if len(seen) >= n {
+			return s, nil
+		}
+		return "", &CheckError{Reason: ReasonTooFewDistinct, Min: n, Actual: len(seen), Err: ErrTooFewDistinct}
+	})
+}
+
+// RejectInvisible returns a validator that will reject passwords
+// containing control characters, format characters like zero width
+// joiners and soft hyphens, and bidirectional overrides with ErrInvisible.
+// Zero width joiners and tags that are part of an emoji sequence,
+// like "👨‍👩‍👧", are allowed.
+// The code point of the first invisible character is returned
+// in the Detail of the error, for instance "U+200D".
+func RejectInvisible() Sanitizer {
+	return SanitizerFunc(func(s string) (string, error) {
+		if strings.IndexFunc(s, isInvisible) < 0 {
+			return s, nil
+		}
+		r := []rune(s)
+		for i, c := range r {
+			if isInvisible(c) && !inEmoji(r, i) {
+				return "", &CheckError{Reason: ReasonInvisible, Detail: fmt.Sprintf("%U", c), Err: ErrInvisible}
+			}
+		}
+		return s, nil
+	})
+}
+
+// StripInvisible returns a sanitizer that will remove the characters
+// rejected by RejectInvisible.
+// Use this before the length is checked, so the stripped
+// characters are not counted.
+func StripInvisible() Sanitizer {
+	return SanitizerFunc(func(s string) (string, error) {
+		if strings.IndexFunc(s, isInvisible) < 0 {
+			return s, nil
+		}
+		r := []rune(s)
+		res := make([]rune, 0, len(r))
+		for i, c := range r {
+			if !isInvisible(c) || inEmoji(r, i) {
+				res = append(res, c)
+			}
+		}
+		return string(res), nil
+	})
+}
+
+// isInvisible returns whether r is a control, format or bidi control
+// character, or is otherwise ignored when displayed.
+func isInvisible(r rune) bool {
+	return unicode.In(r, unicode.Cc, unicode.Cf, unicode.Bidi_Control, unicode.Other_Default_Ignorable_Code_Point)
+}
+
+// inEmoji returns whether r[i] is a zero width joiner between two
+// emoji, or a tag following an emoji, like in subdivision flags.
+func inEmoji(r []rune, i int) bool {
+	if i == 0 {
+		return false
+	}
+	prev := r[i-1]
+	switch c := r[i]; {
+	case c == '\u200d':
+		// The previous emoji may have a variation selector or skin tone.
+		emoji := unicode.Is(unicode.So, prev) || prev == '\ufe0f' || (prev >= 0x1f3fb && prev <= 0x1f3ff)
+		return emoji && i+1 < len(r) && unicode.Is(unicode.So, r[i+1])
+	case isTag(c):
+		return unicode.Is(unicode.So, prev) || (isTag(prev) && prev != 0xe007f)
+	}
+	return false
+}
+
+// isTag returns whether r is in the Unicode tag block used by emoji.
+func isTag(r rune) bool {
+	return r >= 0xe0020 && r <= 0xe007f
+}